@@ -0,0 +1,97 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+
+// pending holds the shapes accumulated between Batch and Flush, not yet drawn
+var pending struct {
+	segments []C.VGubyte
+	coords   []C.VGfloat
+	fill     bool // whether the shapes are filled as well as stroked
+}
+
+// batching is whether Line, Rect, Ellipse, Circle and their outlines are being accumulated
+var batching bool
+
+// Batch starts accumulating the shapes drawn by Line, Rect, RectOutline, Ellipse,
+// EllipseOutline, Circle and CircleOutline, so that runs of them are drawn as a single
+// path, in one call to OpenVG, rather than one call each. Any other call, such as
+// changing the fill color or transformation, first draws the shapes accumulated so far,
+// so a scene is drawn as before, in order; grouping shapes by style makes the runs longer.
+// Shapes drawn together are one path, so where they overlap a translucent fill is blended
+// once, and with the "evenodd" fill rule the overlaps are holes; strokes are drawn after
+// all the fills of the run. Flush ends the batch.
+func Batch() {
+	checkinit()
+	batching = true
+}
+
+// Flush draws the shapes accumulated since Batch, and stops accumulating them; then OpenVG is
+// made to begin drawing everything requested so far, without waiting for it or presenting it.
+// Use RenderFinish to wait for the drawing to complete.
+func Flush() {
+	checkinit()
+	batching = false
+	C.Flush()
+}
+
+// flushbatch draws the accumulated shapes
+func flushbatch() {
+	n := len(pending.segments)
+	if n == 0 {
+		return
+	}
+	C.DrawSegments(C.int(n), &pending.segments[0], &pending.coords[0], cbool(pending.fill), 1)
+	pending.segments = pending.segments[:0]
+	pending.coords = pending.coords[:0]
+}
+
+// batchadd adds a shape to the batch, drawing the batch first if its shapes are filled
+// differently; it reports whether the shape was taken
+func batchadd(fill bool, segments []C.VGubyte, coords ...VGfloat) bool {
+	if !batching {
+		return false
+	}
+	if len(pending.segments) > 0 && pending.fill != fill {
+		flushbatch()
+	}
+	pending.fill = fill
+	pending.segments = append(pending.segments, segments...)
+	for _, c := range coords {
+		pending.coords = append(pending.coords, C.VGfloat(c))
+	}
+	return true
+}
+
+var (
+	linesegments    = []C.VGubyte{C.VG_MOVE_TO_ABS, C.VG_LINE_TO_ABS}
+	rectsegments    = []C.VGubyte{C.VG_MOVE_TO_ABS, C.VG_LINE_TO_ABS, C.VG_LINE_TO_ABS, C.VG_LINE_TO_ABS, C.VG_CLOSE_PATH}
+	ellipsesegments = []C.VGubyte{C.VG_MOVE_TO_ABS, C.VG_SCCWARC_TO_ABS, C.VG_SCCWARC_TO_ABS, C.VG_CLOSE_PATH}
+)
+
+// batchline adds a line to the batch, as Line draws it
+func batchline(x1, y1, x2, y2 VGfloat) bool {
+	return batchadd(false, linesegments, x1, y1, x2, y2)
+}
+
+// batchrect adds a rectangle to the batch, as Rect draws it; the caller leaves out empty ones
+func batchrect(fill bool, x, y, w, h VGfloat) bool {
+	if !batching {
+		return false
+	}
+	return batchadd(fill, rectsegments, x, y, x+w, y, x+w, y+h, x, y+h)
+}
+
+// batchellipse adds an ellipse to the batch, as Ellipse draws it; the caller leaves out empty ones.
+// Ellipses drawn as polygons, as set by SetCircleSegments, are not batched.
+func batchellipse(fill bool, x, y, w, h VGfloat) bool {
+	if !batching || circlesegments > 0 {
+		return false
+	}
+	rx, ry := w/2, h/2
+	return batchadd(fill, ellipsesegments, x+rx, y, rx, ry, 0, x-rx, y, rx, ry, 0, x+rx, y)
+}
@@ -0,0 +1,212 @@
+package openvg
+
+import (
+	"image"
+	"sort"
+)
+
+// DisplayList records drawing calls to be replayed, so that the static parts of a
+// scene can be described once and rendered every frame. Its methods mirror the
+// package functions of the same names; any other call can be recorded with Do.
+type DisplayList struct {
+	ops []func()
+}
+
+// NewDisplayList makes an empty display list
+func NewDisplayList() *DisplayList {
+	return &DisplayList{}
+}
+
+// Render replays the recorded calls, in order
+func (dl *DisplayList) Render() {
+	for _, op := range dl.ops {
+		op()
+	}
+}
+
+// Clear removes all the recorded calls, so the list can be rebuilt
+func (dl *DisplayList) Clear() {
+	dl.ops = dl.ops[:0]
+}
+
+// Len returns the number of recorded calls
+func (dl *DisplayList) Len() int {
+	return len(dl.ops)
+}
+
+// Do records an arbitrary function
+func (dl *DisplayList) Do(f func()) {
+	dl.ops = append(dl.ops, f)
+}
+
+// copyfloats returns a copy of v, so a recording is not changed by later changes to v
+func copyfloats(v []VGfloat) []VGfloat {
+	return append([]VGfloat(nil), v...)
+}
+
+// Line records Line
+func (dl *DisplayList) Line(x1, y1, x2, y2 VGfloat) {
+	dl.Do(func() { Line(x1, y1, x2, y2) })
+}
+
+// Rect records Rect
+func (dl *DisplayList) Rect(x, y, w, h VGfloat) {
+	dl.Do(func() { Rect(x, y, w, h) })
+}
+
+// RectOutline records RectOutline
+func (dl *DisplayList) RectOutline(x, y, w, h VGfloat) {
+	dl.Do(func() { RectOutline(x, y, w, h) })
+}
+
+// Roundrect records Roundrect
+func (dl *DisplayList) Roundrect(x, y, w, h, rw, rh VGfloat) {
+	dl.Do(func() { Roundrect(x, y, w, h, rw, rh) })
+}
+
+// RoundrectOutline records RoundrectOutline
+func (dl *DisplayList) RoundrectOutline(x, y, w, h, rw, rh VGfloat) {
+	dl.Do(func() { RoundrectOutline(x, y, w, h, rw, rh) })
+}
+
+// Ellipse records Ellipse
+func (dl *DisplayList) Ellipse(x, y, w, h VGfloat) {
+	dl.Do(func() { Ellipse(x, y, w, h) })
+}
+
+// EllipseOutline records EllipseOutline
+func (dl *DisplayList) EllipseOutline(x, y, w, h VGfloat) {
+	dl.Do(func() { EllipseOutline(x, y, w, h) })
+}
+
+// Circle records Circle
+func (dl *DisplayList) Circle(x, y, r VGfloat) {
+	dl.Do(func() { Circle(x, y, r) })
+}
+
+// CircleOutline records CircleOutline
+func (dl *DisplayList) CircleOutline(x, y, r VGfloat) {
+	dl.Do(func() { CircleOutline(x, y, r) })
+}
+
+// Arc records Arc
+func (dl *DisplayList) Arc(x, y, w, h, sa, aext VGfloat) {
+	dl.Do(func() { Arc(x, y, w, h, sa, aext) })
+}
+
+// ArcOutline records ArcOutline
+func (dl *DisplayList) ArcOutline(x, y, w, h, sa, aext VGfloat) {
+	dl.Do(func() { ArcOutline(x, y, w, h, sa, aext) })
+}
+
+// Qbezier records Qbezier
+func (dl *DisplayList) Qbezier(sx, sy, cx, cy, ex, ey VGfloat) {
+	dl.Do(func() { Qbezier(sx, sy, cx, cy, ex, ey) })
+}
+
+// Cbezier records Cbezier
+func (dl *DisplayList) Cbezier(sx, sy, cx, cy, px, py, ex, ey VGfloat) {
+	dl.Do(func() { Cbezier(sx, sy, cx, cy, px, py, ex, ey) })
+}
+
+// Polygon records Polygon, copying the coordinates
+func (dl *DisplayList) Polygon(x, y []VGfloat) {
+	x, y = copyfloats(x), copyfloats(y)
+	dl.Do(func() { Polygon(x, y) })
+}
+
+// PolygonOutline records PolygonOutline, copying the coordinates
+func (dl *DisplayList) PolygonOutline(x, y []VGfloat) {
+	x, y = copyfloats(x), copyfloats(y)
+	dl.Do(func() { PolygonOutline(x, y) })
+}
+
+// Polyline records Polyline, copying the coordinates
+func (dl *DisplayList) Polyline(x, y []VGfloat) {
+	x, y = copyfloats(x), copyfloats(y)
+	dl.Do(func() { Polyline(x, y) })
+}
+
+// Text records Text
+func (dl *DisplayList) Text(x, y VGfloat, s string, font string, size int) {
+	dl.Do(func() { Text(x, y, s, font, size) })
+}
+
+// TextMid records TextMid
+func (dl *DisplayList) TextMid(x, y VGfloat, s string, font string, size int) {
+	dl.Do(func() { TextMid(x, y, s, font, size) })
+}
+
+// TextEnd records TextEnd
+func (dl *DisplayList) TextEnd(x, y VGfloat, s string, font string, size int) {
+	dl.Do(func() { TextEnd(x, y, s, font, size) })
+}
+
+// Img records Img; the image is read when the list is rendered
+func (dl *DisplayList) Img(x, y VGfloat, im image.Image) {
+	dl.Do(func() { Img(x, y, im) })
+}
+
+// FillRGB records FillRGB
+func (dl *DisplayList) FillRGB(r, g, b uint8, alpha VGfloat) {
+	dl.Do(func() { FillRGB(r, g, b, alpha) })
+}
+
+// FillColor records FillColor
+func (dl *DisplayList) FillColor(s string, alpha ...VGfloat) {
+	alpha = copyfloats(alpha)
+	dl.Do(func() { FillColor(s, alpha...) })
+}
+
+// StrokeRGB records StrokeRGB
+func (dl *DisplayList) StrokeRGB(r, g, b uint8, alpha VGfloat) {
+	dl.Do(func() { StrokeRGB(r, g, b, alpha) })
+}
+
+// StrokeColor records StrokeColor
+func (dl *DisplayList) StrokeColor(s string, alpha ...VGfloat) {
+	alpha = copyfloats(alpha)
+	dl.Do(func() { StrokeColor(s, alpha...) })
+}
+
+// StrokeWidth records StrokeWidth
+func (dl *DisplayList) StrokeWidth(w VGfloat) {
+	dl.Do(func() { StrokeWidth(w) })
+}
+
+// Translate records Translate
+func (dl *DisplayList) Translate(x, y VGfloat) {
+	dl.Do(func() { Translate(x, y) })
+}
+
+// Rotate records Rotate
+func (dl *DisplayList) Rotate(r VGfloat) {
+	dl.Do(func() { Rotate(r) })
+}
+
+// Scale records Scale
+func (dl *DisplayList) Scale(x, y VGfloat) {
+	dl.Do(func() { Scale(x, y) })
+}
+
+// Shear records Shear
+func (dl *DisplayList) Shear(x, y VGfloat) {
+	dl.Do(func() { Shear(x, y) })
+}
+
+// Drawable is an item of a scene drawn in order of depth by DrawSorted
+type Drawable interface {
+	Z() float64 // depth: items of lower Z are drawn first, so appear behind
+	Draw()
+}
+
+// DrawSorted draws the items back to front, in increasing order of Z, so that nearer
+// items cover farther ones. Items of equal Z are drawn in the order given.
+// The slice itself is not reordered.
+func DrawSorted(items []Drawable) {
+	sorted := append([]Drawable(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Z() < sorted[j].Z() })
+	for _, item := range sorted {
+		item.Draw()
+	}
+}
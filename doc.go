@@ -26,27 +26,37 @@ The typical "hello world" program looks like this:
 		openvg.TextMid(w2, h2, "hello, world", "serif", width/10) // Greetings 
 		openvg.End()                                              // End the picture
 		bufio.NewReader(os.Stdin).ReadBytes('\n')                 // Pause until [RETURN]
-		openvg.Finish()                                           // Graphics cleanup
+		openvg.Shutdown()                                         // Graphics cleanup
 	}
 
 Functions
 
 The Init function provides the necessary graphics subsystem initialization and the dimensions of the whole canvas.
-The Init() call must be paired with a corresponding Finish() call, which performs an orderly shutdown.
+The Init() call must be paired with a corresponding Shutdown() call, which performs an orderly shutdown;
+Finish() is the same, under its older name.
+Drawing before Init, or after Shutdown, panics.
+Init locks the calling goroutine to its operating system thread, which owns the OpenVG state;
+all drawing must be done from that goroutine, and calls from any other panic,
+unless the check is turned off with SetThreadCheck(false).
 
 Typically a "drawing" begins with the Start() call, and ends with End(). A program can have an arbitrary set
 of Start()/End() pairs.
 
 The coordinate system uses float64 coordinates, with the origin at the lower left, with x increasing to the right,
-and y increasing upwards.
+and y increasing upwards. SetOrigin("top-left") changes this, from the next Start, to the origin at the top left,
+with y increasing downwards, as in most screen coordinate systems; text and images stay upright.
 
 Currently, the library provides no mouse or keyboard events, other than those provided by the base operating system.
 It is typical to pause for user input between drawings by reading standard input.
 
 The library's functionally includes shapes, attributes, transformations, text, images, and convenince functions.
 Shape functions include Polygon, Polyline, Cbezier, Qbezier, Rect, Roundrect, Line, Elipse, Circle, and Arc.
+Rectangles, ellipses and circles with a zero or negative width, height or radius draw nothing,
+rather than leaving an OpenVG error, and a negative stroke width is taken as zero.
 Transformation functions are: Translate, Rotate, Shear, and Scale.
 For displaying and measuring text: Text, TextMid, TextEnd, and TextWidth.
+Text is UTF-8; the built-in fonts cover the first 500 Unicode code points (Latin-1 and most extended Latin),
+and characters outside a font's range are skipped.
 The attribute functions are StrokeColor, StrokeRGB, StrokeWidth, and FillRGB, FillColor, FillLinearGradient, and FillRadialGradient. 
 Colors are specfied with RGB triples (0-255) with alpha values (0.0-1.0), or named colors as specified by the SVG standard.
 
@@ -7,8 +7,14 @@ typedef struct {
 	int32_t window_y;
 	uint32_t window_width;
 	uint32_t window_height;
+	// dispmanx display, 0 for the default
+	uint32_t display_id;
 	// dispman window 
 	DISPMANX_ELEMENT_HANDLE_T element;
+	// dispman window opacity, 0 = transparent, 255 = opaque
+	uint32_t window_alpha;
+	// dispman layer; windows on higher layers are shown over those on lower ones
+	int32_t window_layer;
 
 	// EGL data
 	EGLDisplay display;
@@ -18,5 +24,9 @@ typedef struct {
 } STATE_T;
 
 extern void oglinit(STATE_T *);
+extern int pbufferinit(STATE_T *);
+extern int sharedinit(STATE_T *, EGLContext *, EGLSurface *);
+extern void shareddestroy(STATE_T *, EGLContext, EGLSurface);
 extern void dispmanMoveWindow(STATE_T *, int, int);
 extern void dispmanChangeWindowOpacity(STATE_T *, unsigned int);
+extern void dispmanChangeWindowLayer(STATE_T *, int);
@@ -0,0 +1,83 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+
+// VGError is an OpenVG error code
+type VGError int
+
+// OpenVG error codes
+const (
+	BadHandleError              VGError = C.VG_BAD_HANDLE_ERROR
+	IllegalArgumentError        VGError = C.VG_ILLEGAL_ARGUMENT_ERROR
+	OutOfMemoryError            VGError = C.VG_OUT_OF_MEMORY_ERROR
+	PathCapabilityError         VGError = C.VG_PATH_CAPABILITY_ERROR
+	UnsupportedImageFormatError VGError = C.VG_UNSUPPORTED_IMAGE_FORMAT_ERROR
+	UnsupportedPathFormatError  VGError = C.VG_UNSUPPORTED_PATH_FORMAT_ERROR
+	ImageInUseError             VGError = C.VG_IMAGE_IN_USE_ERROR
+	NoContextError              VGError = C.VG_NO_CONTEXT_ERROR
+)
+
+// vgerrors describes the error codes
+var vgerrors = map[VGError]string{
+	BadHandleError:              "bad handle",
+	IllegalArgumentError:        "illegal argument",
+	OutOfMemoryError:            "out of memory",
+	PathCapabilityError:         "path capability",
+	UnsupportedImageFormatError: "unsupported image format",
+	UnsupportedPathFormatError:  "unsupported path format",
+	ImageInUseError:             "image in use",
+	NoContextError:              "no context",
+}
+
+func (e VGError) Error() string {
+	if s, ok := vgerrors[e]; ok {
+		return "openvg: " + s
+	}
+	return "openvg: unknown error"
+}
+
+// Fatal reports whether drawing cannot continue after the error: running out of memory,
+// or losing the context. Other errors only mean that the call causing them had no effect.
+func (e VGError) Fatal() bool {
+	return e == OutOfMemoryError || e == NoContextError
+}
+
+// errorhook is called with each error found before a drawing call, if set
+var errorhook func(error)
+
+// LastError returns the oldest error recorded by OpenVG since the last check, as a VGError,
+// or nil if there is none, and clears it. A call that causes an error, such as a
+// negative dimension, otherwise silently has no effect. An error hook set by SetErrorHook
+// is not called with the error returned.
+func LastError() error {
+	checkthread() // not checkinit, whose error hook would take the error
+	flushbatch()
+	return vgerror()
+}
+
+// vgerror reads and clears the OpenVG error
+func vgerror() error {
+	if e := C.GetError(); e != C.VG_NO_ERROR {
+		return VGError(e)
+	}
+	return nil
+}
+
+// SetErrorHook arranges for f to be called with any OpenVG error, checked at the start
+// of each drawing call, so it reports the error of the call before. This costs a check
+// per call, so is intended for debugging; SetErrorHook(nil) turns the checking off.
+func SetErrorHook(f func(error)) {
+	errorhook = f
+}
+
+// checkerror passes any pending error to the error hook
+func checkerror() {
+	if err := vgerror(); err != nil {
+		errorhook(err)
+	}
+}
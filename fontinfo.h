@@ -11,6 +11,15 @@ extern "C" {
 		int descender_height;
 		int font_height;
 		VGPath Glyphs[500];
+		// outline data, as loaded, for GlyphOutline
+		const int *Points;
+		const int *PointIndices;
+		const unsigned char *Instructions;
+		const int *InstructionIndices;
+		const int *InstructionCounts;
+		// kerning pairs: left glyph, right glyph, adjustment, sorted by glyph
+		const int *Kerning;
+		int KernCount;
 	} Fontinfo;
 
 	extern Fontinfo SansTypeface, SerifTypeface, MonoTypeface, HelveticaTypeface;
@@ -108,6 +108,7 @@ int main (int argc, char * const argv[])
 	std::vector<char>		givec;
 	std::vector<float>		gbbox;
 	std::vector<float>		advances;
+	std::vector<FT_UInt>		ftglyphs;	// freetype glyph index of each glyph written
 
         float                           global_miny = 1000000.0f;
         float                           global_maxy = -10000000.0f;
@@ -142,6 +143,7 @@ int main (int argc, char * const argv[])
 				advances.push_back(advance);
 
 				//write glyph index to character map
+				ftglyphs.push_back(glyphIndex);
 				characterMap[cc] = glyphs++;
 				continue;
 			}
@@ -244,6 +246,7 @@ int main (int argc, char * const argv[])
 			memcpy( &(givec[size]), &(ivec[0]), ivec.size() * sizeof(char) );
 
 			//write glyph index to character map
+			ftglyphs.push_back(glyphIndex);
 			characterMap[cc] = glyphs++;
 		}
 	}
@@ -380,6 +383,37 @@ int main (int argc, char * const argv[])
 		fprintf (f,"%d%c",characterMap[i],(i==(NGLYPHS-1))?' ':',');
 	}
 	fprintf (f,"};\n\n");
+
+	//print the kerning pairs as left glyph, right glyph, adjustment, sorted by glyph
+	std::vector<int> kerning;
+	if( FT_HAS_KERNING( face ) )
+	{
+		for(int l=0;l<glyphs;l++)
+		{
+			for(int r=0;r<glyphs;r++)
+			{
+				FT_Vector delta;
+				if( !FT_Get_Kerning( face, ftglyphs[l], ftglyphs[r], FT_KERNING_UNFITTED, &delta ) && delta.x != 0 )
+				{
+					kerning.push_back(l);
+					kerning.push_back(r);
+					kerning.push_back((int)(65536.0f*convFTFixed(delta.x)));
+				}
+			}
+		}
+	}
+	if( kerning.size() )
+	{
+		fprintf (f,"#define %s_kerningCount %d\n",argv[3],(int)(kerning.size()/3));
+		fprintf (f,"static const int %s_kerning[%d*3] = {", argv[3],(int)(kerning.size()/3));
+		for(int i=0;i<kerning.size();i+=3)
+		{
+			if (((i/3) % 10)==0)
+				fprintf (f,"\n    ");
+			fprintf (f,"%d,%d,%d%c",kerning[i],kerning[i+1],kerning[i+2],(i==(kerning.size()-3))?' ':',');
+		}
+		fprintf (f,"};\n\n");
+	}
 	fclose(f);
 
 	if(glyphs)
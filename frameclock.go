@@ -0,0 +1,71 @@
+package openvg
+
+import "time"
+
+// fpsSmoothing is the weight given to each new frame in the FrameClock's FPS average
+const fpsSmoothing = 0.1
+
+// FrameClock measures the time between frames of an animation, typically ticked once
+// per frame after End
+type FrameClock struct {
+	last time.Time
+	fps  float64
+}
+
+// NewFrameClock makes a frame clock, starting now
+func NewFrameClock() *FrameClock {
+	return &FrameClock{last: time.Now()}
+}
+
+// Tick returns the seconds since the previous Tick, or since the clock was made
+func (fc *FrameClock) Tick() float64 {
+	now := time.Now()
+	dt := now.Sub(fc.last).Seconds()
+	fc.last = now
+	if dt > 0 {
+		if fc.fps == 0 {
+			fc.fps = 1 / dt
+		} else {
+			fc.fps += (1/dt - fc.fps) * fpsSmoothing
+		}
+	}
+	return dt
+}
+
+// FPS returns the frame rate, averaged over recent ticks
+func (fc *FrameClock) FPS() float64 {
+	return fc.fps
+}
+
+// Throttle sleeps until a frame at targetFPS frames per second is due, that is,
+// until 1/targetFPS seconds after the previous Tick. Call it just before Tick.
+// Zero or negative targets do not sleep.
+func (fc *FrameClock) Throttle(targetFPS int) {
+	if targetFPS <= 0 {
+		return
+	}
+	due := fc.last.Add(time.Second / time.Duration(targetFPS))
+	if d := time.Until(due); d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// Animate runs an animation loop: each frame it begins the picture with Start, calls frame
+// with the seconds elapsed since the loop began, and presents the picture with End, until
+// frame returns false. Frames are paced to fps frames per second; with fps zero or less they
+// are paced only by End, which waits for vsync after SwapInterval(1). The picture of the
+// frame returning false is still presented.
+func Animate(fps int, frame func(t float64) bool) {
+	fc := NewFrameClock()
+	start := fc.last
+	for {
+		Start(winwidth, winheight)
+		more := frame(time.Since(start).Seconds())
+		End()
+		if !more {
+			return
+		}
+		fc.Throttle(fps)
+		fc.Tick()
+	}
+}
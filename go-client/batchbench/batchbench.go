@@ -0,0 +1,75 @@
+// batchbench: compare frame times drawing many shapes with and without Batch,
+// and with and without the check of the drawing thread
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"math/rand"
+	"time"
+)
+
+type shape struct {
+	x, y, size openvg.VGfloat
+}
+
+// scene draws the shapes, a run of each color, returning the time taken to draw and present it
+func scene(width, height int, colors []string, shapes [][]shape, batch bool) time.Duration {
+	begin := time.Now()
+	openvg.Start(width, height)
+	openvg.BackgroundColor("black")
+	if batch {
+		openvg.Batch()
+	}
+	for i, run := range shapes {
+		openvg.FillColor(colors[i], 0.8)
+		for _, s := range run {
+			if i%2 == 0 {
+				openvg.Circle(s.x, s.y, s.size)
+			} else {
+				openvg.Rect(s.x, s.y, s.size, s.size)
+			}
+		}
+	}
+	if batch {
+		openvg.Flush()
+	}
+	openvg.End()
+	return time.Since(begin)
+}
+
+func main() {
+	var n = flag.Int("n", 5000, "number of shapes")
+	var frames = flag.Int("f", 100, "frames drawn each way")
+	flag.Parse()
+
+	width, height := openvg.Init()
+	fw := openvg.VGfloat(width)
+	fh := openvg.VGfloat(height)
+	openvg.SwapInterval(0) // time the drawing, not the display
+
+	colors := []string{"steelblue", "orange", "seagreen", "crimson", "gold"}
+	shapes := make([][]shape, len(colors))
+	for i := 0; i < *n; i++ {
+		c := i % len(colors)
+		s := shape{openvg.VGfloat(rand.Float32()) * fw, openvg.VGfloat(rand.Float32()) * fh, openvg.VGfloat(4 + rand.Intn(16))}
+		shapes[c] = append(shapes[c], s)
+	}
+
+	// each way with and without the check of the drawing thread made by each call
+	var direct, batched, unchecked, uncheckedbatched time.Duration
+	for i := 0; i < *frames; i++ {
+		direct += scene(width, height, colors, shapes, false)
+		batched += scene(width, height, colors, shapes, true)
+		openvg.SetThreadCheck(false)
+		unchecked += scene(width, height, colors, shapes, false)
+		uncheckedbatched += scene(width, height, colors, shapes, true)
+		openvg.SetThreadCheck(true)
+	}
+	openvg.Finish()
+	f := time.Duration(*frames)
+	fmt.Printf("%d shapes, %d frames each\n", *n, *frames)
+	fmt.Printf("direct:  %v per frame, %v without the thread check\n", direct/f, unchecked/f)
+	fmt.Printf("batched: %v per frame, %v without the thread check\n", batched/f, uncheckedbatched/f)
+}
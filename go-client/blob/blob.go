@@ -0,0 +1,41 @@
+// blob: a closed shape built from several bezier segments, filled as one region
+package main
+
+import (
+	"bufio"
+	"github.com/ajstarks/openvg"
+	"os"
+)
+
+func main() {
+	width, height := openvg.Init()
+	w := openvg.VGfloat(width)
+	h := openvg.VGfloat(height)
+	cx, cy, r := w/2, h/2, h/4
+
+	openvg.Start(width, height)
+	openvg.BackgroundColor("white")
+
+	// Each segment continues from where the last one ended,
+	// so the start point is given only once, by MoveTo
+	blob := openvg.NewPath()
+	blob.MoveTo(cx-r, cy)
+	blob.CubicTo(cx-r, cy+r, cx, cy+r*1.4, cx+r*0.3, cy+r*0.8)
+	blob.QuadTo(cx+r*0.6, cy+r*0.3, cx+r, cy+r*0.2)
+	blob.CubicTo(cx+r*1.5, cy, cx+r, cy-r*1.2, cx, cy-r*0.9)
+	blob.QuadTo(cx-r, cy-r*0.8, cx-r, cy)
+	blob.Close()
+
+	openvg.FillColor("steelblue")
+	openvg.StrokeColor("navy")
+	openvg.StrokeWidth(4)
+	blob.Draw(true, true) // fill and stroke the whole shape at once
+
+	openvg.FillColor("black")
+	openvg.TextMid(cx, h*0.1, "One path, four curves", "sans", width/40)
+	openvg.End()
+
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+	blob.Destroy()
+	openvg.Finish()
+}
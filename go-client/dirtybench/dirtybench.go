@@ -0,0 +1,78 @@
+// dirtybench: compare frame times updating a clock's seconds by redrawing the whole
+// window and by redrawing only the seconds with DirtyRect
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"math/rand"
+	"time"
+)
+
+type dot struct {
+	x, y, r openvg.VGfloat
+}
+
+// backdrop draws the unchanging part of the picture
+func backdrop(dots []dot) {
+	openvg.BackgroundColor("midnightblue")
+	openvg.FillColor("slategray", 0.5)
+	for _, d := range dots {
+		openvg.Circle(d.x, d.y, d.r)
+	}
+}
+
+// seconds draws the changing part, the seconds, at (x,y)
+func seconds(x, y openvg.VGfloat, size, sec int) {
+	openvg.FillColor("white")
+	openvg.TextEnd(x, y, fmt.Sprintf("%02d", sec), "mono", size)
+}
+
+func main() {
+	var n = flag.Int("n", 2000, "number of shapes in the backdrop")
+	var frames = flag.Int("f", 100, "frames drawn each way")
+	flag.Parse()
+
+	width, height := openvg.Init()
+	fw := openvg.VGfloat(width)
+	fh := openvg.VGfloat(height)
+	openvg.SwapInterval(0) // time the drawing, not the display
+
+	dots := make([]dot, *n)
+	for i := range dots {
+		dots[i] = dot{openvg.VGfloat(rand.Float32()) * fw, openvg.VGfloat(rand.Float32()) * fh, openvg.VGfloat(10 + rand.Intn(40))}
+	}
+	size := height / 10
+	x, y := fw-openvg.VGfloat(size), fh/2
+	// the seconds' box, generously covering the glyphs
+	bx, by, bw, bh := int(x)-size*2, int(y)-size/2, size*2+size/4, size*2
+
+	begin := time.Now()
+	for i := 0; i < *frames; i++ {
+		openvg.Start(width, height)
+		backdrop(dots)
+		seconds(x, y, size, i%60)
+		openvg.End()
+	}
+	full := time.Since(begin)
+
+	// the window keeps its picture after End, so the backdrop is drawn once,
+	// then redrawn only where the seconds change
+	openvg.Start(width, height)
+	backdrop(dots)
+	openvg.End()
+	begin = time.Now()
+	for i := 0; i < *frames; i++ {
+		openvg.DirtyRect(bx, by, bw, bh)
+		backdrop(dots)
+		seconds(x, y, size, i%60)
+		openvg.ScissorDisable()
+		openvg.End()
+	}
+	dirty := time.Since(begin)
+	openvg.Shutdown()
+	fmt.Printf("%d backdrop shapes, %d frames each\n", *n, *frames)
+	fmt.Printf("whole window: %v per frame\n", full/time.Duration(*frames))
+	fmt.Printf("DirtyRect:    %v per frame\n", dirty/time.Duration(*frames))
+}
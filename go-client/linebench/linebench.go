@@ -0,0 +1,62 @@
+// linebench: compare frame times drawing many line segments with Lines and with Line
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"math/rand"
+	"time"
+)
+
+// scene draws the segments, returning the time taken to draw and present them
+func scene(width, height int, segments [][4]openvg.VGfloat, batch bool) time.Duration {
+	begin := time.Now()
+	openvg.Start(width, height)
+	openvg.BackgroundColor("black")
+	openvg.StrokeColor("lightsteelblue")
+	openvg.StrokeWidth(1)
+	if batch {
+		openvg.Lines(segments)
+	} else {
+		for _, s := range segments {
+			openvg.Line(s[0], s[1], s[2], s[3])
+		}
+	}
+	openvg.End()
+	return time.Since(begin)
+}
+
+func main() {
+	var n = flag.Int("n", 10000, "number of segments")
+	var frames = flag.Int("f", 100, "frames drawn each way")
+	flag.Parse()
+
+	width, height := openvg.Init()
+	fw := openvg.VGfloat(width)
+	fh := openvg.VGfloat(height)
+	openvg.SwapInterval(0) // time the drawing, not the display
+
+	// a random walk, as a plot of noisy data would be
+	segments := make([][4]openvg.VGfloat, *n)
+	x, y := openvg.VGfloat(0), fh/2
+	for i := range segments {
+		nx := fw * openvg.VGfloat(i+1) / openvg.VGfloat(*n)
+		ny := y + openvg.VGfloat(rand.NormFloat64())*fh/50
+		if ny < 0 || ny > fh {
+			ny = fh / 2
+		}
+		segments[i] = [4]openvg.VGfloat{x, y, nx, ny}
+		x, y = nx, ny
+	}
+
+	var direct, batched time.Duration
+	for i := 0; i < *frames; i++ {
+		direct += scene(width, height, segments, false)
+		batched += scene(width, height, segments, true)
+	}
+	openvg.Shutdown()
+	fmt.Printf("%d segments, %d frames each\n", *n, *frames)
+	fmt.Printf("Line:  %v per frame\n", direct/time.Duration(*frames))
+	fmt.Printf("Lines: %v per frame\n", batched/time.Duration(*frames))
+}
@@ -0,0 +1,63 @@
+// pointbench: compare frame times drawing many points with Circle, Points and Dots
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"math/rand"
+	"time"
+)
+
+// scene draws the points as specified, returning the time taken to draw and present them
+func scene(width, height int, x, y []openvg.VGfloat, how string) time.Duration {
+	begin := time.Now()
+	openvg.Start(width, height)
+	openvg.BackgroundColor("black")
+	openvg.FillColor("orange")
+	switch how {
+	case "circle":
+		for i := range x {
+			openvg.Circle(x[i], y[i], 2)
+		}
+	case "points":
+		openvg.Points(x, y, 1) // the same size as Circle(x, y, 2), whose size is a diameter
+	case "dots":
+		openvg.Dots(x, y)
+	}
+	openvg.End()
+	return time.Since(begin)
+}
+
+func main() {
+	var n = flag.Int("n", 50000, "number of points")
+	var frames = flag.Int("f", 100, "frames drawn each way")
+	flag.Parse()
+
+	width, height := openvg.Init()
+	fw := openvg.VGfloat(width)
+	fh := openvg.VGfloat(height)
+	openvg.SwapInterval(0) // time the drawing, not the display
+
+	// a scatter plot of two correlated variables
+	x := make([]openvg.VGfloat, *n)
+	y := make([]openvg.VGfloat, *n)
+	for i := range x {
+		v := rand.NormFloat64()
+		x[i] = fw/2 + openvg.VGfloat(v)*fw/8
+		y[i] = fh/2 + openvg.VGfloat(v*0.6+rand.NormFloat64()*0.4)*fh/8
+	}
+
+	ways := []string{"circle", "points", "dots"}
+	times := make([]time.Duration, len(ways))
+	for i := 0; i < *frames; i++ {
+		for w, how := range ways {
+			times[w] += scene(width, height, x, y, how)
+		}
+	}
+	openvg.Shutdown()
+	fmt.Printf("%d points, %d frames each\n", *n, *frames)
+	fmt.Printf("Circle: %v per frame\n", times[0]/time.Duration(*frames))
+	fmt.Printf("Points: %v per frame\n", times[1]/time.Duration(*frames))
+	fmt.Printf("Dots:   %v per frame\n", times[2]/time.Duration(*frames))
+}
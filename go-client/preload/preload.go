@@ -0,0 +1,70 @@
+// preload: decode images in the background while showing a loading screen
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"image"
+	"os"
+	"time"
+)
+
+func main() {
+	files := os.Args[1:]
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: preload image...")
+		os.Exit(1)
+	}
+	width, height := openvg.Init()
+	w := openvg.VGfloat(width)
+	h := openvg.VGfloat(height)
+
+	// start decoding everything at once; nothing is drawn from the decoding goroutines
+	pending := make([]<-chan image.Image, len(files))
+	loaded := 0
+	for i, f := range files {
+		c, err := openvg.LoadImageAsync(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			loaded++ // nothing to wait for; a nil channel never delivers
+		}
+		pending[i] = c
+	}
+
+	// draw the loading screen until every image has arrived
+	images := make([]image.Image, len(files))
+	for loaded < len(files) {
+		for i, c := range pending {
+			select {
+			case im := <-c:
+				images[i] = im
+				pending[i] = nil
+				loaded++
+			default:
+			}
+		}
+		openvg.Start(width, height)
+		openvg.BackgroundColor("black")
+		openvg.FillColor("white")
+		openvg.TextMid(w/2, h/2, fmt.Sprintf("Loading %d of %d", loaded, len(files)), "sans", width/40)
+		openvg.FillColor("steelblue")
+		openvg.Rect(w*0.2, h*0.4, w*0.6*openvg.VGfloat(loaded)/openvg.VGfloat(len(files)), h/40)
+		openvg.End()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// show the images side by side, drawn here on the drawing goroutine
+	openvg.Start(width, height)
+	openvg.BackgroundColor("black")
+	cell := width / len(files)
+	for i, im := range images {
+		if im != nil {
+			openvg.ImageFit(openvg.VGfloat(i*cell), 0, cell, height, "contain", im)
+		}
+	}
+	openvg.End()
+
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+	openvg.Finish()
+}
@@ -0,0 +1,59 @@
+// textbench: compare frame times drawing many labels with Texts and with Text
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ajstarks/openvg"
+	"math/rand"
+	"time"
+)
+
+// scene draws the labels, returning the time taken to draw and present them
+func scene(width, height int, labels []openvg.Label, batch bool) time.Duration {
+	begin := time.Now()
+	openvg.Start(width, height)
+	openvg.BackgroundColor("black")
+	openvg.FillColor("white")
+	if batch {
+		openvg.Texts(labels)
+	} else {
+		for _, l := range labels {
+			openvg.Text(l.X, l.Y, l.S, l.Font, l.Size)
+		}
+	}
+	openvg.End()
+	return time.Since(begin)
+}
+
+func main() {
+	var n = flag.Int("n", 500, "number of labels")
+	var frames = flag.Int("f", 100, "frames drawn each way")
+	flag.Parse()
+
+	width, height := openvg.Init()
+	fw := openvg.VGfloat(width)
+	fh := openvg.VGfloat(height)
+	openvg.SwapInterval(0) // time the drawing, not the display
+
+	labels := make([]openvg.Label, *n)
+	for i := range labels {
+		labels[i] = openvg.Label{
+			X:    openvg.VGfloat(rand.Float32()) * fw,
+			Y:    openvg.VGfloat(rand.Float32()) * fh,
+			S:    fmt.Sprintf("%.2f", rand.Float64()*1000),
+			Font: "sans",
+			Size: 10,
+		}
+	}
+
+	var direct, batched time.Duration
+	for i := 0; i < *frames; i++ {
+		direct += scene(width, height, labels, false)
+		batched += scene(width, height, labels, true)
+	}
+	openvg.Shutdown()
+	fmt.Printf("%d labels, %d frames each\n", *n, *frames)
+	fmt.Printf("Text:  %v per frame\n", direct/time.Duration(*frames))
+	fmt.Printf("Texts: %v per frame\n", batched/time.Duration(*frames))
+}
@@ -0,0 +1,87 @@
+package openvg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// UpdateGolden is the environment variable which, when set to any non-empty value,
+// makes CompareImage write the image as the new golden image instead of comparing
+const UpdateGolden = "OPENVG_UPDATE_GOLDEN"
+
+// MismatchError is returned by CompareImage when an image differs from the golden image
+type MismatchError struct {
+	Golden string       // golden image file
+	Count  int          // number of differing pixels
+	Diff   *image.NRGBA // differing pixels in red, others pale gray; nil if the sizes differ
+}
+
+func (e *MismatchError) Error() string {
+	if e.Diff == nil {
+		return fmt.Sprintf("openvg: image size differs from %s", e.Golden)
+	}
+	return fmt.Sprintf("openvg: %d pixels differ from %s", e.Count, e.Golden)
+}
+
+// CompareImage compares an image, such as a Snapshot of offscreen drawing, with the
+// golden PNG image in goldenPath, reporting whether they match. Pixels match if each of
+// their red, green, blue and alpha values differ by no more than tolerance (0.0-1.0).
+// On a mismatch the error is a *MismatchError, holding an image of the differences.
+// If the UpdateGolden environment variable is set, the image is written to goldenPath instead.
+func CompareImage(got image.Image, goldenPath string, tolerance float64) (bool, error) {
+	if os.Getenv(UpdateGolden) != "" {
+		f, err := os.Create(goldenPath)
+		if err != nil {
+			return false, err
+		}
+		if err := png.Encode(f, got); err != nil {
+			f.Close()
+			return false, err
+		}
+		return true, f.Close()
+	}
+	f, err := os.Open(goldenPath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		return false, err
+	}
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Size() != wb.Size() {
+		return false, &MismatchError{Golden: goldenPath}
+	}
+	limit := int(tolerance*255 + 0.5)
+	diff := image.NewNRGBA(image.Rect(0, 0, gb.Dx(), gb.Dy()))
+	n := 0
+	for y := 0; y < gb.Dy(); y++ {
+		for x := 0; x < gb.Dx(); x++ {
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.NRGBA)
+			if absdiff(g.R, w.R) > limit || absdiff(g.G, w.G) > limit || absdiff(g.B, w.B) > limit || absdiff(g.A, w.A) > limit {
+				diff.SetNRGBA(x, y, color.NRGBA{255, 0, 0, 255})
+				n++
+			} else {
+				v := uint8(128 + (int(g.R)+int(g.G)+int(g.B))/12) // a pale gray, for context
+				diff.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+			}
+		}
+	}
+	if n > 0 {
+		return false, &MismatchError{Golden: goldenPath, Count: n, Diff: diff}
+	}
+	return true, nil
+}
+
+// absdiff returns the absolute difference of two color values
+func absdiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,48 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import "fmt"
+
+// Gradient is a gradient made once and set as the fill any number of times, so that
+// animations using the same gradient every frame need not rebuild it.
+type Gradient struct {
+	paint C.VGPaint
+}
+
+// CreateLinearGradient makes a linear gradient between (x1,y1) and (x2,y2), using the
+// specified offsets and colors in ramp, as FillLinearGradient does, without changing the fill.
+// Call Destroy when it is no longer needed.
+func CreateLinearGradient(x1, y1, x2, y2 VGfloat, ramp []Offcolor) (*Gradient, error) {
+	checkinit()
+	cr, nr := makeramp(ramp)
+	if nr == 0 {
+		return nil, fmt.Errorf("openvg: gradient has no colors")
+	}
+	p := C.CreateLinearGradient(C.VGfloat(x1), C.VGfloat(y1), C.VGfloat(x2), C.VGfloat(y2), cr, nr)
+	if p == C.VG_INVALID_HANDLE {
+		return nil, fmt.Errorf("openvg: unable to make a gradient")
+	}
+	return &Gradient{paint: p}, nil
+}
+
+// SetFill makes the gradient the fill for subsequent shapes and text
+func (g *Gradient) SetFill() {
+	checkinit()
+	if g.paint != C.VG_INVALID_HANDLE {
+		C.SetFillPaint(g.paint)
+	}
+}
+
+// Destroy frees the gradient
+func (g *Gradient) Destroy() {
+	checkinit()
+	if g.paint != C.VG_INVALID_HANDLE {
+		C.DestroyGradient(g.paint)
+		g.paint = C.VG_INVALID_HANDLE
+	}
+}
@@ -0,0 +1,37 @@
+package openvg
+
+// PointInRect reports whether (px,py) is inside the rectangle at (x,y) with dimensions (w,h),
+// as drawn by Rect. Like Rect, zero or negative dimensions contain no points.
+func PointInRect(px, py, x, y, w, h VGfloat) bool {
+	if emptyshape(w, h) {
+		return false
+	}
+	return px >= x && px <= x+w && py >= y && py <= y+h
+}
+
+// PointInCircle reports whether (px,py) is inside the circle centered at (cx,cy) with diameter d,
+// as drawn by Circle. Like Circle, a zero or negative diameter contains no points.
+func PointInCircle(px, py, cx, cy, d VGfloat) bool {
+	if emptyshape(d, d) {
+		return false
+	}
+	dx, dy, r := px-cx, py-cy, d/2
+	return dx*dx+dy*dy <= r*r
+}
+
+// PointInPolygon reports whether (px,py) is inside the polygon with vertices in xs, ys,
+// using the even-odd rule. Mismatched coordinate slices, or fewer than three vertices, contain no points.
+func PointInPolygon(px, py VGfloat, xs, ys []VGfloat) bool {
+	n := len(xs)
+	if n != len(ys) || n < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		// count the edges crossed by a ray from the point toward +x
+		if (ys[i] > py) != (ys[j] > py) && px < (xs[j]-xs[i])*(py-ys[i])/(ys[j]-ys[i])+xs[i] {
+			inside = !inside
+		}
+	}
+	return inside
+}
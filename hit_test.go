@@ -0,0 +1,63 @@
+package openvg
+
+import "testing"
+
+func TestPointInRect(t *testing.T) {
+	tests := []struct {
+		px, py, x, y, w, h VGfloat
+		want               bool
+	}{
+		{5, 5, 0, 0, 10, 10, true},
+		{0, 0, 0, 0, 10, 10, true},
+		{10, 10, 0, 0, 10, 10, true},
+		{11, 5, 0, 0, 10, 10, false},
+		{5, -1, 0, 0, 10, 10, false},
+		{-5, -5, 0, 0, -10, -10, false},
+		{0, 0, 0, 0, 0, 10, false},
+	}
+	for _, tt := range tests {
+		if got := PointInRect(tt.px, tt.py, tt.x, tt.y, tt.w, tt.h); got != tt.want {
+			t.Errorf("PointInRect(%g, %g, %g, %g, %g, %g) = %v, want %v", tt.px, tt.py, tt.x, tt.y, tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestPointInCircle(t *testing.T) {
+	tests := []struct {
+		px, py, cx, cy, d VGfloat
+		want              bool
+	}{
+		{50, 50, 50, 50, 20, true},
+		{60, 50, 50, 50, 20, true},  // on the edge: the radius is half the diameter
+		{65, 50, 50, 50, 20, false}, // within the diameter, but outside what Circle draws
+		{57, 57, 50, 50, 20, true},
+		{58, 58, 50, 50, 20, false},
+		{50, 50, 50, 50, 0, false},
+		{50, 50, 50, 50, -20, false},
+	}
+	for _, tt := range tests {
+		if got := PointInCircle(tt.px, tt.py, tt.cx, tt.cy, tt.d); got != tt.want {
+			t.Errorf("PointInCircle(%g, %g, %g, %g, %g) = %v, want %v", tt.px, tt.py, tt.cx, tt.cy, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPointInPolygon(t *testing.T) {
+	xs, ys := []VGfloat{0, 10, 10, 0}, []VGfloat{0, 0, 10, 10}
+	tests := []struct {
+		px, py VGfloat
+		xs, ys []VGfloat
+		want   bool
+	}{
+		{5, 5, xs, ys, true},
+		{15, 5, xs, ys, false},
+		{5, -5, xs, ys, false},
+		{5, 5, xs, ys[:3], false},
+		{5, 5, xs[:2], ys[:2], false},
+	}
+	for _, tt := range tests {
+		if got := PointInPolygon(tt.px, tt.py, tt.xs, tt.ys); got != tt.want {
+			t.Errorf("PointInPolygon(%g, %g, %v, %v) = %v, want %v", tt.px, tt.py, tt.xs, tt.ys, got, tt.want)
+		}
+	}
+}
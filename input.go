@@ -0,0 +1,205 @@
+package openvg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// PointerEvent reports the pointer position in window coordinates
+// (origin at lower left), and the buttons held down.
+// For a touchscreen, a touch is reported as ButtonLeft.
+type PointerEvent struct {
+	X, Y    int
+	Buttons uint
+}
+
+// Pointer buttons
+const (
+	ButtonLeft = 1 << iota
+	ButtonRight
+	ButtonMiddle
+)
+
+// evdev event types and codes, from linux/input-event-codes.h
+const (
+	evSyn           = 0x00
+	evKey           = 0x01
+	evRel           = 0x02
+	evAbs           = 0x03
+	relX            = 0x00
+	relY            = 0x01
+	absX            = 0x00
+	absY            = 0x01
+	absMTPositionX  = 0x35
+	absMTPositionY  = 0x36
+	btnLeft         = 0x110
+	btnRight        = 0x111
+	btnMiddle       = 0x112
+	btnTouch        = 0x14a
+	inputDevicesDir = "/dev/input/"
+)
+
+// inputEvent is the evdev struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// absInfo is the evdev struct input_absinfo
+type absInfo struct {
+	Value, Minimum, Maximum, Fuzz, Flat, Resolution int32
+}
+
+var (
+	pointerOnce sync.Once
+	pointerChan chan PointerEvent
+)
+
+// PointerChannel returns a channel of pointer events, read from the first
+// touchscreen found, or else the first mouse, under /dev/input.
+// The channel is closed if no device is found or it cannot be read
+// (reading evdev devices usually requires membership of the "input" group).
+// Init must be called first, so positions can be mapped to the window.
+func PointerChannel() <-chan PointerEvent {
+	pointerOnce.Do(func() {
+		pointerChan = make(chan PointerEvent, 64)
+		go readpointer(pointerChan)
+	})
+	return pointerChan
+}
+
+// finddevice returns the event device of the first touchscreen, or failing that, mouse,
+// listed in /proc/bus/input/devices, and whether it reports absolute positions
+func finddevice() (string, bool) {
+	f, err := os.Open("/proc/bus/input/devices")
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	var mouse, handler string
+	var ev uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "H: Handlers="):
+			handler = ""
+			for _, h := range strings.Fields(line[len("H: Handlers="):]) {
+				if strings.HasPrefix(h, "event") {
+					handler = h
+				}
+			}
+		case strings.HasPrefix(line, "B: EV="):
+			ev, _ = strconv.ParseUint(line[len("B: EV="):], 16, 64)
+		case line == "": // end of a device
+			if handler != "" && ev&(1<<evAbs) != 0 && ev&(1<<evKey) != 0 {
+				return inputDevicesDir + handler, true
+			}
+			if handler != "" && ev&(1<<evRel) != 0 && mouse == "" {
+				mouse = inputDevicesDir + handler
+			}
+			handler, ev = "", 0
+		}
+	}
+	return mouse, false
+}
+
+// absrange returns the minimum and maximum of an absolute axis
+func absrange(f *os.File, axis uintptr) (int32, int32) {
+	var info absInfo
+	// EVIOCGABS(axis) is _IOR('E', 0x40 + axis, struct input_absinfo)
+	req := uintptr(2<<30) | unsafe.Sizeof(info)<<16 | 'E'<<8 | (0x40 + axis)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(&info)))
+	if errno != 0 || info.Maximum <= info.Minimum {
+		return 0, 0
+	}
+	return info.Minimum, info.Maximum
+}
+
+// readpointer reads events from the pointer device, sending one PointerEvent
+// for each completed report, until the device cannot be read
+func readpointer(c chan<- PointerEvent) {
+	defer close(c)
+	name, absolute := finddevice()
+	if name == "" {
+		return
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	w, h := winwidth, winheight
+	var xmin, xmax, ymin, ymax int32
+	if absolute {
+		xmin, xmax = absrange(f, absX)
+		ymin, ymax = absrange(f, absY)
+	}
+	x, y := w/2, h/2
+	var buttons uint
+	var ev inputEvent
+	for {
+		if err := binary.Read(f, binary.LittleEndian, &ev); err != nil {
+			return
+		}
+		switch ev.Type {
+		case evRel:
+			switch ev.Code {
+			case relX:
+				x += int(ev.Value)
+			case relY:
+				y -= int(ev.Value) // evdev y increases downward
+			}
+		case evAbs:
+			switch ev.Code {
+			case absX, absMTPositionX:
+				if xmax > xmin {
+					x = int(int64(ev.Value-xmin) * int64(w) / int64(xmax-xmin+1))
+				}
+			case absY, absMTPositionY:
+				if ymax > ymin {
+					y = h - 1 - int(int64(ev.Value-ymin)*int64(h)/int64(ymax-ymin+1))
+				}
+			}
+		case evKey:
+			var b uint
+			switch ev.Code {
+			case btnLeft, btnTouch:
+				b = ButtonLeft
+			case btnRight:
+				b = ButtonRight
+			case btnMiddle:
+				b = ButtonMiddle
+			}
+			if ev.Value != 0 {
+				buttons |= b
+			} else {
+				buttons &^= b
+			}
+		case evSyn:
+			x = clampint(x, 0, w-1)
+			y = clampint(y, 0, h-1)
+			c <- PointerEvent{X: x, Y: y, Buttons: buttons}
+		}
+	}
+}
+
+// clampint limits v to the range lo..hi
+func clampint(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,84 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import "fmt"
+
+// Layer is an offscreen image that can be drawn into like the window, then drawn
+// onto the window any number of times, so that parts of a scene which change at
+// different rates, such as a background and a display of readings, can be kept apart.
+type Layer struct {
+	image         C.VGImage
+	width, height int
+}
+
+// drawinglayer is the layer between Begin and End, if any
+var drawinglayer *Layer
+
+// NewLayer makes a transparent layer of dimensions (w,h); call Destroy when it is no longer needed
+func NewLayer(w, h int) (*Layer, error) {
+	checkinit()
+	if w <= 0 || h <= 0 {
+		return nil, fmt.Errorf("openvg: invalid layer size %dx%d", w, h)
+	}
+	im := C.NewLayer(C.int(w), C.int(h))
+	if im == C.VG_INVALID_HANDLE {
+		return nil, fmt.Errorf("openvg: unable to make a %dx%d layer", w, h)
+	}
+	return &Layer{image: im, width: w, height: h}, nil
+}
+
+// Begin directs subsequent drawing into the layer, until End. Drawing starts untransformed,
+// with the origin at the lower left of the layer (or the top left, as set by SetOrigin);
+// the style is unchanged. Layers do not nest: drawing into one must end before another begins.
+func (l *Layer) Begin() error {
+	checkinit()
+	if drawinglayer != nil {
+		return fmt.Errorf("openvg: a layer is already being drawn")
+	}
+	if C.LayerBegin(l.image) != 0 {
+		return fmt.Errorf("openvg: unable to draw into the layer")
+	}
+	drawinglayer = l
+	return nil
+}
+
+// End returns drawing to the window, restoring the transformation in effect at Begin
+func (l *Layer) End() {
+	checkinit()
+	if drawinglayer != l {
+		return
+	}
+	C.LayerEnd()
+	drawinglayer = nil
+}
+
+// Draw draws the layer with its lower left corner at (x,y), through the current transformation
+// and blended with the drawing, like ImageFit
+func (l *Layer) Draw(x, y VGfloat) {
+	checkinit()
+	C.LayerDraw(l.image, C.VGfloat(x), C.VGfloat(y), C.int(l.width), C.int(l.height))
+}
+
+// Clear clears the layer to transparent
+func (l *Layer) Clear() {
+	checkinit()
+	C.LayerClear(l.image, C.int(l.width), C.int(l.height))
+}
+
+// Size returns the dimensions of the layer
+func (l *Layer) Size() (w, h int) {
+	return l.width, l.height
+}
+
+// Destroy frees the layer, ending drawing into it if need be
+func (l *Layer) Destroy() {
+	checkinit()
+	l.End()
+	C.LayerDestroy(l.image)
+	l.image = C.VG_INVALID_HANDLE
+}
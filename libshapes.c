@@ -7,6 +7,8 @@
 //
 #include <stdio.h>
 #include <stdlib.h>
+#include <string.h>
+#include <math.h>
 #include <termios.h>
 #include <assert.h>
 #include <jpeglib.h>
@@ -20,6 +22,7 @@
 #include "Helvetica.inc"
 #include "eglstate.h"					   // data structures for graphics state
 #include "fontinfo.h"					   // font data structure
+#include "shapes.h"					   // C API
 
 static STATE_T _state, *state = &_state;	// global graphics state
 static const int MAXFONTPATH = 500;
@@ -27,6 +30,36 @@ static int init_x = 0;		// Initial window position and size
 static int init_y = 0;
 static unsigned int init_w = 0;
 static unsigned int init_h = 0;
+static int init_layer = 0;	// Initial window layer
+static int masking = 0;		// shapes render to the mask between MaskBegin and MaskEnd
+static int clipdepth = 0;	// number of clip paths in effect
+static VGMaskLayer cliplayer = VG_INVALID_HANDLE;	// enclosing clip, while defining a nested one
+static VGPaint gradientpaint = VG_INVALID_HANDLE;	// reused by the gradient fill functions
+static VGPaint fillpaint = VG_INVALID_HANDLE;	// reused by setfill
+static VGPaint strokepaint = VG_INVALID_HANDLE;	// reused by setstroke
+static VGPaint savedpaint = VG_INVALID_HANDLE;	// fill saved by FillSave
+static VGfloat savedcolor[4];	// colour of fillpaint saved by FillSave
+static VGbitfield savedoff = 0;	// whether filling was off at FillSave
+static VGbitfield paintoff = 0;	// paint modes turned off by FillNone and StrokeNone
+static int topleft = 0;		// origin at the top left, y increasing down, set by Origin
+static int circlesegments = 0;	// segments approximating a circle, or 0 for arcs, set by SetCircleSegments
+static int npaints = 0;		// paints made and not yet destroyed, reported by PaintCount
+
+// style is the paint and stroke state recorded by SaveStyle. The reused paints
+// in effect are handed over to the saved style, so later colour and gradient
+// changes create new ones, leaving the saved paints untouched.
+typedef struct {
+	VGPaint fill, stroke;	// paints in use
+	VGPaint fillpaint, gradientpaint, strokepaint;	// reused paints handed over
+	VGbitfield paintoff;
+	VGfloat width, miter, dashphase;
+	VGint cap, join, ndash;
+	VGfloat *dash;
+} style;
+
+static style *styles = NULL;	// stack of saved styles
+static int nstyles = 0, stylecap = 0;
+static int unsaved = 0;		// styles not saved for lack of memory, for RestoreStyle to skip
 //
 // Terminal settings
 //
@@ -86,6 +119,13 @@ Fontinfo loadfont(const int *Points,
 	}
 	f.CharacterMap = cmap;
 	f.GlyphAdvances = adv;
+	f.Points = Points;
+	f.PointIndices = PointIndices;
+	f.Instructions = Instructions;
+	f.InstructionIndices = InstructionIndices;
+	f.InstructionCounts = InstructionCounts;
+	f.Kerning = NULL;
+	f.KernCount = 0;
 	f.Count = ng;
 	f.descender_height = 0;
 	f.font_height = 0;
@@ -197,20 +237,193 @@ VGImage createImageFromJpeg(const char *filename) {
 	return img;
 }
 
+// windowy converts the y of a region of height h from the origin set by Origin to window coordinates,
+// which have their origin at the lower left
+static VGint windowy(VGint y, VGint h) {
+	return topleft ? (VGint) state->window_height - y - h : y;
+}
+
 // makeimage makes an image from a raw raster of red, green, blue, alpha values
 void makeimage(VGfloat x, VGfloat y, int w, int h, VGubyte * data) {
-	unsigned int dstride = w * 4;
-	VGImageFormat rgbaFormat = VG_sABGR_8888;
-	VGImage img = vgCreateImage(rgbaFormat, w, h, VG_IMAGE_QUALITY_BETTER);
-	vgImageSubData(img, (void *)data, dstride, rgbaFormat, 0, 0, w, h);
-	vgSetPixels(x, y, img, 0, 0, w, h);
+	makeimageformat(x, y, w, h, data, VG_sABGR_8888, w * 4);
+}
+
+// makeimageformat makes an image from a raw raster in the specified format, such as VG_sL_8
+// for one byte of gray per pixel, with stride bytes from the start of one row to the next
+void makeimageformat(VGfloat x, VGfloat y, int w, int h, VGubyte * data, VGImageFormat format, int stride) {
+	VGImage img = vgCreateImage(format, w, h, VG_IMAGE_QUALITY_BETTER);
+	vgImageSubData(img, (void *)data, stride, format, 0, 0, w, h);
+	vgSetPixels(x, windowy(y, h), img, 0, 0, w, h);
 	vgDestroyImage(img);
 }
 
+// placeimage draws an image of dimensions (iw,ih) scaled to (w,h) at (x,y), through the current transform
+static void placeimage(VGImage img, VGfloat x, VGfloat y, VGfloat w, VGfloat h, int iw, int ih) {
+	VGfloat mm[9];
+	vgGetMatrix(mm);
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_IMAGE_USER_TO_SURFACE);
+	vgLoadMatrix(mm);
+	if (topleft) {				   // keep the image upright
+		vgTranslate(x, y + h);
+		vgScale(w / iw, -h / ih);
+	} else {
+		vgTranslate(x, y);
+		vgScale(w / iw, h / ih);
+	}
+	vgDrawImage(img);
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_PATH_USER_TO_SURFACE);
+}
+
+// DrawImage draws image data of dimensions (iw,ih), red, green, blue, alpha bytes, bottom row first,
+// scaled to (w,h) at (x,y). Unlike makeimage, the image is transformed and blended like other drawing.
+void DrawImage(VGfloat x, VGfloat y, VGfloat w, VGfloat h, int iw, int ih, VGubyte * data) {
+	VGImage img = vgCreateImage(VG_sABGR_8888, iw, ih,
+				    VG_IMAGE_QUALITY_NONANTIALIASED | VG_IMAGE_QUALITY_FASTER | VG_IMAGE_QUALITY_BETTER);
+	vgImageSubData(img, (void *)data, iw * 4, VG_sABGR_8888, 0, 0, iw, ih);
+	placeimage(img, x, y, w, h, iw, ih);
+	vgDestroyImage(img);
+}
+
+//
+// Layers
+//
+
+static EGLSurface layersurface = EGL_NO_SURFACE;	// surface of the layer being drawn, if any
+static VGfloat layermatrix[9];	// transform in effect at LayerBegin
+
+// NewLayer makes a transparent image of dimensions (w,h) to draw into with LayerBegin,
+// returning VG_INVALID_HANDLE if it cannot be made
+VGImage NewLayer(int w, int h) {
+	VGImage img = vgCreateImage(VG_sRGBA_8888_PRE, w, h,
+				    VG_IMAGE_QUALITY_NONANTIALIASED | VG_IMAGE_QUALITY_FASTER | VG_IMAGE_QUALITY_BETTER);
+	if (img != VG_INVALID_HANDLE) {
+		LayerClear(img, w, h);
+	}
+	return img;
+}
+
+// LayerClear clears a layer to transparent
+void LayerClear(VGImage img, int w, int h) {
+	VGfloat clear[4], none[4] = { 0, 0, 0, 0 };
+	vgGetfv(VG_CLEAR_COLOR, 4, clear);
+	vgSetfv(VG_CLEAR_COLOR, 4, none);
+	vgClearImage(img, 0, 0, w, h);
+	vgSetfv(VG_CLEAR_COLOR, 4, clear);
+}
+
+// LayerBegin directs drawing into a layer until LayerEnd, returning 0 on success,
+// or -1 if the layer cannot be drawn into. Layers do not nest. Drawing in the layer
+// starts untransformed, with the origin at the corner of the layer set by Origin.
+int LayerBegin(VGImage img) {
+	static EGLConfig config = NULL;
+	static const EGLint attribute_list[] = {
+		EGL_RED_SIZE, 8,
+		EGL_GREEN_SIZE, 8,
+		EGL_BLUE_SIZE, 8,
+		EGL_ALPHA_SIZE, 8,
+		EGL_ALPHA_MASK_SIZE, 8,
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_RENDERABLE_TYPE, EGL_OPENVG_BIT,
+		EGL_NONE
+	};
+	EGLint num_config;
+
+	if (layersurface != EGL_NO_SURFACE) {
+		return -1;
+	}
+	if (config == NULL && (eglChooseConfig(state->display, attribute_list, &config, 1, &num_config) == EGL_FALSE || num_config < 1)) {
+		config = NULL;
+		return -1;
+	}
+	layersurface = eglCreatePbufferFromClientBuffer(state->display, EGL_OPENVG_IMAGE, (EGLClientBuffer) (size_t) img, config, NULL);
+	if (layersurface == EGL_NO_SURFACE) {
+		return -1;
+	}
+	if (eglMakeCurrent(state->display, layersurface, layersurface, state->context) == EGL_FALSE) {
+		eglDestroySurface(state->display, layersurface);
+		layersurface = EGL_NO_SURFACE;
+		return -1;
+	}
+	vgGetMatrix(layermatrix);
+	vgLoadIdentity();
+	if (topleft) {
+		vgTranslate(0, vgGetParameteri(img, VG_IMAGE_HEIGHT));
+		vgScale(1, -1);
+	}
+	return 0;
+}
+
+// LayerEnd returns drawing from a layer to the window
+void LayerEnd() {
+	if (layersurface == EGL_NO_SURFACE) {
+		return;
+	}
+	eglMakeCurrent(state->display, state->surface, state->surface, state->context);
+	eglDestroySurface(state->display, layersurface);
+	layersurface = EGL_NO_SURFACE;
+	vgLoadMatrix(layermatrix);
+}
+
+// LayerDestroy frees a layer
+void LayerDestroy(VGImage img) {
+	vgDestroyImage(img);
+}
+
+// sharedcontext is a context made by NewSharedContext
+typedef struct {
+	EGLContext context;
+	EGLSurface surface;
+} sharedcontext;
+
+// NewSharedContext makes an OpenVG context sharing images with the main one, current on the
+// calling thread, which must not be the main one, returning NULL if it cannot be made
+void *NewSharedContext() {
+	sharedcontext *s = malloc(sizeof(sharedcontext));
+	if (s == NULL) {
+		return NULL;
+	}
+	if (sharedinit(state, &s->context, &s->surface) != 0) {
+		free(s);
+		return NULL;
+	}
+	return s;
+}
+
+// DestroySharedContext frees a context made by NewSharedContext, on the thread that made it
+void DestroySharedContext(void *s) {
+	sharedcontext *sc = s;
+	shareddestroy(state, sc->context, sc->surface);
+	free(sc);
+}
+
+// SharedUpload makes a layer of dimensions (w,h) holding data, red, green, blue, alpha bytes,
+// bottom row first, in the calling thread's shared context, waiting until it is complete so
+// that the main thread can draw it; returns VG_INVALID_HANDLE if it cannot be made
+VGImage SharedUpload(int w, int h, VGubyte * data) {
+	VGImage img = vgCreateImage(VG_sRGBA_8888_PRE, w, h,
+				    VG_IMAGE_QUALITY_NONANTIALIASED | VG_IMAGE_QUALITY_FASTER | VG_IMAGE_QUALITY_BETTER);
+	if (img != VG_INVALID_HANDLE) {
+		vgImageSubData(img, (void *)data, w * 4, VG_sABGR_8888, 0, 0, w, h);
+		vgFinish();
+	}
+	return img;
+}
+
+// LayerDraw draws a layer of dimensions (w,h) with its lower left corner at (x,y),
+// transformed and blended like other drawing
+void LayerDraw(VGImage img, VGfloat x, VGfloat y, int w, int h) {
+	placeimage(img, x, y, w, h, w, h);
+}
+
+// ImageQuality sets how images drawn by DrawImage are resampled when scaled or transformed
+void ImageQuality(VGImageQuality q) {
+	vgSeti(VG_IMAGE_QUALITY, q);
+}
+
 // Image places an image at the specifed location
 void Image(VGfloat x, VGfloat y, int w, int h, const char *filename) {
 	VGImage img = createImageFromJpeg(filename);
-	vgSetPixels(x, y, img, 0, 0, w, h);
+	vgSetPixels(x, windowy(y, h), img, 0, 0, w, h);
 	vgDestroyImage(img);
 }
 
@@ -222,6 +435,20 @@ void dumpscreen(int w, int h, FILE * fp) {
 	free(ScreenBuffer);
 }
 
+// readformat is the colour space ReadPixels converts to
+static VGImageFormat readformat = VG_sABGR_8888;
+
+// ReadColorSpace sets whether ReadPixels returns linear values, or sRGB (the default)
+void ReadColorSpace(int linear) {
+	readformat = linear ? VG_lABGR_8888 : VG_sABGR_8888;
+}
+
+// ReadPixels copies a region of the surface into data as red, green, blue, alpha bytes,
+// bottom row first, converted to the colour space set by ReadColorSpace
+void ReadPixels(int x, int y, int w, int h, VGubyte * data) {
+	vgReadPixels(data, w * 4, readformat, x, windowy(y, h), w, h);
+}
+
 Fontinfo SansTypeface, SerifTypeface, MonoTypeface, HelveticaTypeface;
 
 // initWindowSize requests a specific window size & position, if not called
@@ -234,15 +461,14 @@ void initWindowSize(int x, int y, unsigned int w, unsigned int h) {
 	init_h = h;
 }
 
-// init sets the system to its initial state
-void init(int *w, int *h) {
-	bcm_host_init();
-	memset(state, 0, sizeof(*state));
-	state->window_x = init_x;
-	state->window_y = init_y;
-	state->window_width = init_w;
-	state->window_height = init_h;
-	oglinit(state);
+// initWindowLayer requests the dispmanx layer of the window opened by init();
+// windows on higher layers are shown over those on lower ones, 0 if not called
+void initWindowLayer(int layer) {
+	init_layer = layer;
+}
+
+// loadfonts loads the built-in typefaces
+static void loadfonts() {
 	SansTypeface = loadfont(DejaVuSans_glyphPoints,
 				DejaVuSans_glyphPointIndices,
 				DejaVuSans_glyphInstructions,
@@ -251,6 +477,10 @@ void init(int *w, int *h) {
 				DejaVuSans_glyphAdvances, DejaVuSans_characterMap, DejaVuSans_glyphCount);
 	SansTypeface.descender_height = DejaVuSans_descender_height;
 	SansTypeface.font_height = DejaVuSans_font_height;
+#ifdef DejaVuSans_kerningCount
+	SansTypeface.Kerning = DejaVuSans_kerning;
+	SansTypeface.KernCount = DejaVuSans_kerningCount;
+#endif
 
 	SerifTypeface = loadfont(DejaVuSerif_glyphPoints,
 				 DejaVuSerif_glyphPointIndices,
@@ -260,6 +490,10 @@ void init(int *w, int *h) {
 				 DejaVuSerif_glyphAdvances, DejaVuSerif_characterMap, DejaVuSerif_glyphCount);
 	SerifTypeface.descender_height = DejaVuSerif_descender_height;
 	SerifTypeface.font_height = DejaVuSerif_font_height;
+#ifdef DejaVuSerif_kerningCount
+	SerifTypeface.Kerning = DejaVuSerif_kerning;
+	SerifTypeface.KernCount = DejaVuSerif_kerningCount;
+#endif
 
 	MonoTypeface = loadfont(DejaVuSansMono_glyphPoints,
 				DejaVuSansMono_glyphPointIndices,
@@ -269,6 +503,10 @@ void init(int *w, int *h) {
 				DejaVuSansMono_glyphAdvances, DejaVuSansMono_characterMap, DejaVuSansMono_glyphCount);
 	MonoTypeface.descender_height = DejaVuSansMono_descender_height;
 	MonoTypeface.font_height = DejaVuSansMono_font_height;
+#ifdef DejaVuSansMono_kerningCount
+	MonoTypeface.Kerning = DejaVuSansMono_kerning;
+	MonoTypeface.KernCount = DejaVuSansMono_kerningCount;
+#endif
 	HelveticaTypeface = loadfont(Helvetica_glyphPoints,
                 Helvetica_glyphPointIndices,
                 Helvetica_glyphInstructions,
@@ -277,16 +515,68 @@ void init(int *w, int *h) {
                 Helvetica_glyphAdvances, Helvetica_characterMap, Helvetica_glyphCount);
 	HelveticaTypeface.descender_height = Helvetica_descender_height;
 	HelveticaTypeface.font_height = Helvetica_font_height;
+#ifdef Helvetica_kerningCount
+	HelveticaTypeface.Kerning = Helvetica_kerning;
+	HelveticaTypeface.KernCount = Helvetica_kerningCount;
+#endif
+}
+
+// init sets the system to its initial state
+void init(int *w, int *h) {
+	initDisplay(0, w, h);
+}
+
+// initDisplay sets the system to its initial state, drawing to the dispmanx display id
+void initDisplay(int id, int *w, int *h) {
+	bcm_host_init();
+	memset(state, 0, sizeof(*state));
+	state->display_id = id;
+	state->window_x = init_x;
+	state->window_y = init_y;
+	state->window_width = init_w;
+	state->window_height = init_h;
+	state->window_layer = init_layer;
+	oglinit(state);
+	loadfonts();
 	*w = state->window_width;
 	*h = state->window_height;
 }
 
+// DisplaySize gets the dimensions of the dispmanx display id, returning 0 if it is
+// connected, -1 otherwise. It can be called before initialization.
+int DisplaySize(int id, int *w, int *h) {
+	uint32_t dw, dh;
+	bcm_host_init();
+	if (graphics_get_display_size(id, &dw, &dh) < 0 || dw == 0 || dh == 0) {
+		return -1;
+	}
+	*w = dw;
+	*h = dh;
+	return 0;
+}
+
+// initOffscreen sets the system to its initial state, drawing to an offscreen
+// surface of the specified dimensions instead of the display.
+// Returns 0 on success, -1 if the surface could not be created.
+int initOffscreen(int w, int h) {
+	bcm_host_init();
+	memset(state, 0, sizeof(*state));
+	state->screen_width = state->window_width = w;
+	state->screen_height = state->window_height = h;
+	if (pbufferinit(state) != 0) {
+		return -1;
+	}
+	loadfonts();
+	return 0;
+}
+
 // finish cleans up
 void finish() {
 	unloadfont(SansTypeface.Glyphs, SansTypeface.Count);
 	unloadfont(SerifTypeface.Glyphs, SerifTypeface.Count);
 	unloadfont(MonoTypeface.Glyphs, MonoTypeface.Count);
 	unloadfont(HelveticaTypeface.Glyphs, HelveticaTypeface.Count);
+	DestroyPaint();
 	eglSwapBuffers(state->display, state->surface);
 	eglMakeCurrent(state->display, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
 	eglDestroySurface(state->display, state->surface);
@@ -318,26 +608,81 @@ void Scale(VGfloat x, VGfloat y) {
 	vgScale(x, y);
 }
 
+// GetMatrix copies the current user to surface transform into the 9 values of m
+void GetMatrix(VGfloat *m) {
+	vgGetMatrix(m);
+}
+
+// LoadMatrix replaces the current user to surface transform with the 9 values of m
+void LoadMatrix(const VGfloat *m) {
+	vgLoadMatrix(m);
+}
+
 //
 // Style functions
 //
 
+// newpaint makes a paint, counting it
+static VGPaint newpaint() {
+	VGPaint p = vgCreatePaint();
+	if (p != VG_INVALID_HANDLE) {
+		npaints++;
+	}
+	return p;
+}
+
+// destroypaint frees a paint, if there is one
+static void destroypaint(VGPaint p) {
+	if (p != VG_INVALID_HANDLE) {
+		vgDestroyPaint(p);
+		npaints--;
+	}
+}
+
+// PaintCount returns the number of paints made by this library and not yet destroyed,
+// to check that a program does not leak them
+int PaintCount() {
+	return npaints;
+}
+
 // setfill sets the fill color
 void setfill(VGfloat color[4]) {
-	VGPaint fillPaint = vgCreatePaint();
-	vgSetParameteri(fillPaint, VG_PAINT_TYPE, VG_PAINT_TYPE_COLOR);
-	vgSetParameterfv(fillPaint, VG_PAINT_COLOR, 4, color);
-	vgSetPaint(fillPaint, VG_FILL_PATH);
-	vgDestroyPaint(fillPaint);
+	if (fillpaint == VG_INVALID_HANDLE) {
+		fillpaint = newpaint();
+		vgSetParameteri(fillpaint, VG_PAINT_TYPE, VG_PAINT_TYPE_COLOR);
+	}
+	vgSetParameterfv(fillpaint, VG_PAINT_COLOR, 4, color);
+	vgSetPaint(fillpaint, VG_FILL_PATH);
+	paintoff &= ~VG_FILL_PATH;
+}
+
+// FillSave records the current fill, colour or gradient, for FillRestore
+void FillSave() {
+	savedpaint = vgGetPaint(VG_FILL_PATH);
+	if (savedpaint != VG_INVALID_HANDLE && savedpaint == fillpaint) {
+		vgGetParameterfv(fillpaint, VG_PAINT_COLOR, 4, savedcolor);
+	}
+	savedoff = paintoff & VG_FILL_PATH;
+}
+
+// FillRestore returns to the fill recorded by FillSave
+void FillRestore() {
+	if (savedpaint != VG_INVALID_HANDLE && savedpaint == fillpaint) {
+		vgSetParameterfv(fillpaint, VG_PAINT_COLOR, 4, savedcolor);
+	}
+	vgSetPaint(savedpaint, VG_FILL_PATH);
+	paintoff = (paintoff & ~VG_FILL_PATH) | savedoff;
 }
 
 // setstroke sets the stroke color
 void setstroke(VGfloat color[4]) {
-	VGPaint strokePaint = vgCreatePaint();
-	vgSetParameteri(strokePaint, VG_PAINT_TYPE, VG_PAINT_TYPE_COLOR);
-	vgSetParameterfv(strokePaint, VG_PAINT_COLOR, 4, color);
-	vgSetPaint(strokePaint, VG_STROKE_PATH);
-	vgDestroyPaint(strokePaint);
+	if (strokepaint == VG_INVALID_HANDLE) {
+		strokepaint = newpaint();
+		vgSetParameteri(strokepaint, VG_PAINT_TYPE, VG_PAINT_TYPE_COLOR);
+	}
+	vgSetParameterfv(strokepaint, VG_PAINT_COLOR, 4, color);
+	vgSetPaint(strokepaint, VG_STROKE_PATH);
+	paintoff &= ~VG_STROKE_PATH;
 }
 
 // StrokeWidth sets the stroke width
@@ -347,6 +692,117 @@ void StrokeWidth(VGfloat width) {
 	vgSeti(VG_STROKE_JOIN_STYLE, VG_JOIN_MITER);
 }
 
+// SetStrokeWidth sets the stroke width, keeping the cap and join styles
+void SetStrokeWidth(VGfloat width) {
+	vgSetf(VG_STROKE_LINE_WIDTH, width);
+}
+
+// GetStrokeWidth returns the current stroke width
+VGfloat GetStrokeWidth() {
+	return vgGetf(VG_STROKE_LINE_WIDTH);
+}
+
+// GetStrokeStyle returns the current stroke cap and join styles, and miter limit
+void GetStrokeStyle(VGint *cap, VGint *join, VGfloat *miter) {
+	*cap = vgGeti(VG_STROKE_CAP_STYLE);
+	*join = vgGeti(VG_STROKE_JOIN_STYLE);
+	*miter = vgGetf(VG_STROKE_MITER_LIMIT);
+}
+
+// paintcolor copies the color of the paint for mode into c; the alpha is -1
+// if the paint is off, or is not a color, but a gradient or pattern
+static void paintcolor(VGPaintMode mode, VGfloat c[4]) {
+	VGPaint p = vgGetPaint(mode);
+	if (paintoff & mode) {
+		c[3] = -1;
+	} else if (p == VG_INVALID_HANDLE) {	// the default paint, opaque black
+		c[0] = c[1] = c[2] = 0;
+		c[3] = 1;
+	} else if (vgGetParameteri(p, VG_PAINT_TYPE) != VG_PAINT_TYPE_COLOR) {
+		c[3] = -1;
+	} else {
+		vgGetParameterfv(p, VG_PAINT_COLOR, 4, c);
+	}
+}
+
+// GetPaint returns the current fill and stroke colors, each with an alpha of -1 if the paint
+// is off or not a color, and the stroke width
+void GetPaint(VGfloat * fill, VGfloat * stroke, VGfloat * width) {
+	paintcolor(VG_FILL_PATH, fill);
+	paintcolor(VG_STROKE_PATH, stroke);
+	*width = vgGetf(VG_STROKE_LINE_WIDTH);
+}
+
+// SaveStyle pushes the fill and stroke paints, the stroke width, cap, join and
+// miter limit, and the dash pattern onto the style stack, for RestoreStyle.
+// Without the memory to grow the stack, the style is counted as unsaved, as are
+// those saved after it, so that their RestoreStyles leave the style unchanged.
+void SaveStyle() {
+	if (unsaved > 0) {
+		unsaved++;
+		return;
+	}
+	if (nstyles == stylecap) {
+		int n = stylecap == 0 ? 8 : stylecap * 2;
+		style *st = realloc(styles, n * sizeof(style));
+		if (st == NULL) {
+			unsaved++;
+			return;
+		}
+		styles = st;
+		stylecap = n;
+	}
+	style *st = &styles[nstyles++];
+	st->fill = vgGetPaint(VG_FILL_PATH);
+	st->stroke = vgGetPaint(VG_STROKE_PATH);
+	st->fillpaint = fillpaint;
+	st->gradientpaint = gradientpaint;
+	st->strokepaint = strokepaint;
+	fillpaint = gradientpaint = strokepaint = VG_INVALID_HANDLE;
+	st->paintoff = paintoff;
+	st->width = vgGetf(VG_STROKE_LINE_WIDTH);
+	st->miter = vgGetf(VG_STROKE_MITER_LIMIT);
+	st->cap = vgGeti(VG_STROKE_CAP_STYLE);
+	st->join = vgGeti(VG_STROKE_JOIN_STYLE);
+	st->dashphase = vgGetf(VG_STROKE_DASH_PHASE);
+	st->ndash = vgGetVectorSize(VG_STROKE_DASH_PATTERN);
+	st->dash = NULL;
+	if (st->ndash > 0 && (st->dash = malloc(st->ndash * sizeof(VGfloat))) != NULL) {
+		vgGetfv(VG_STROKE_DASH_PATTERN, st->ndash, st->dash);
+	} else {
+		st->ndash = 0;
+	}
+}
+
+// RestoreStyle pops the style saved by the matching SaveStyle, making it current;
+// without one, or if it was not saved, it does nothing
+void RestoreStyle() {
+	if (unsaved > 0) {
+		unsaved--;
+		return;
+	}
+	if (nstyles == 0) {
+		return;
+	}
+	style *st = &styles[--nstyles];
+	destroypaint(fillpaint);
+	destroypaint(gradientpaint);
+	destroypaint(strokepaint);
+	fillpaint = st->fillpaint;
+	gradientpaint = st->gradientpaint;
+	strokepaint = st->strokepaint;
+	vgSetPaint(st->fill, VG_FILL_PATH);
+	vgSetPaint(st->stroke, VG_STROKE_PATH);
+	paintoff = st->paintoff;
+	vgSetf(VG_STROKE_LINE_WIDTH, st->width);
+	vgSetf(VG_STROKE_MITER_LIMIT, st->miter);
+	vgSeti(VG_STROKE_CAP_STYLE, st->cap);
+	vgSeti(VG_STROKE_JOIN_STYLE, st->join);
+	vgSetf(VG_STROKE_DASH_PHASE, st->dashphase);
+	vgSetfv(VG_STROKE_DASH_PATTERN, st->ndash, st->dash);
+	free(st->dash);
+}
+
 //
 // Color functions
 //
@@ -391,116 +847,596 @@ void Fill(unsigned int r, unsigned int g, unsigned int b, VGfloat a) {
 	setfill(color);
 }
 
-// setstops sets color stops for gradients
-void setstop(VGPaint paint, VGfloat * stops, int n) {
+// setramp sets the color stops of a gradient paint
+static void setramp(VGPaint paint, VGfloat * stops, int n) {
 	VGboolean multmode = VG_FALSE;
 	VGColorRampSpreadMode spreadmode = VG_COLOR_RAMP_SPREAD_REPEAT;
 	vgSetParameteri(paint, VG_PAINT_COLOR_RAMP_SPREAD_MODE, spreadmode);
 	vgSetParameteri(paint, VG_PAINT_COLOR_RAMP_PREMULTIPLIED, multmode);
 	vgSetParameterfv(paint, VG_PAINT_COLOR_RAMP_STOPS, 5 * n, stops);
+}
+
+// setstops sets color stops for gradients
+void setstop(VGPaint paint, VGfloat * stops, int n) {
+	setramp(paint, stops, n);
 	vgSetPaint(paint, VG_FILL_PATH);
+	paintoff &= ~VG_FILL_PATH;
+}
+
+// gradient returns the paint used for gradient and pattern fills, creating it if needed.
+// Reusing one paint keeps animations that change gradients every frame
+// from creating and destroying a paint object each time.
+VGPaint gradient() {
+	if (gradientpaint == VG_INVALID_HANDLE) {
+		gradientpaint = newpaint();
+	}
+	return gradientpaint;
+}
+
+// DestroyPaint frees the paints used for colours and gradient fills, and any saved styles;
+// the next fill or stroke creates a new paint
+void DestroyPaint() {
+	destroypaint(gradientpaint);
+	destroypaint(fillpaint);
+	destroypaint(strokepaint);
+	gradientpaint = fillpaint = strokepaint = VG_INVALID_HANDLE;
+	savedpaint = VG_INVALID_HANDLE;
+	// free the paints held by saved styles
+	for (; nstyles > 0; nstyles--) {
+		style *st = &styles[nstyles - 1];
+		destroypaint(st->fillpaint);
+		destroypaint(st->gradientpaint);
+		destroypaint(st->strokepaint);
+		free(st->dash);
+	}
+	unsaved = 0;
 }
 
 // LinearGradient fills with a linear gradient
 void FillLinearGradient(VGfloat x1, VGfloat y1, VGfloat x2, VGfloat y2, VGfloat * stops, int ns) {
 	VGfloat lgcoord[4] = { x1, y1, x2, y2 };
-	VGPaint paint = vgCreatePaint();
+	VGPaint paint = gradient();
 	vgSetParameteri(paint, VG_PAINT_TYPE, VG_PAINT_TYPE_LINEAR_GRADIENT);
 	vgSetParameterfv(paint, VG_PAINT_LINEAR_GRADIENT, 4, lgcoord);
 	setstop(paint, stops, ns);
-	vgDestroyPaint(paint);
 }
 
 // RadialGradient fills with a linear gradient
 void FillRadialGradient(VGfloat cx, VGfloat cy, VGfloat fx, VGfloat fy, VGfloat radius, VGfloat * stops, int ns) {
 	VGfloat radialcoord[5] = { cx, cy, fx, fy, radius };
-	VGPaint paint = vgCreatePaint();
+	VGPaint paint = gradient();
 	vgSetParameteri(paint, VG_PAINT_TYPE, VG_PAINT_TYPE_RADIAL_GRADIENT);
 	vgSetParameterfv(paint, VG_PAINT_RADIAL_GRADIENT, 5, radialcoord);
 	setstop(paint, stops, ns);
-	vgDestroyPaint(paint);
+}
+
+// CreateLinearGradient makes a paint holding a linear gradient between (x1,y1) and (x2,y2),
+// for use with SetFillPaint, without changing the fill
+VGPaint CreateLinearGradient(VGfloat x1, VGfloat y1, VGfloat x2, VGfloat y2, VGfloat * stops, int ns) {
+	VGfloat lgcoord[4] = { x1, y1, x2, y2 };
+	VGPaint paint = newpaint();
+	if (paint == VG_INVALID_HANDLE) {
+		return paint;
+	}
+	vgSetParameteri(paint, VG_PAINT_TYPE, VG_PAINT_TYPE_LINEAR_GRADIENT);
+	vgSetParameterfv(paint, VG_PAINT_LINEAR_GRADIENT, 4, lgcoord);
+	setramp(paint, stops, ns);
+	return paint;
+}
+
+// SetFillPaint fills with a paint made by CreateLinearGradient
+void SetFillPaint(VGPaint paint) {
+	vgSetPaint(paint, VG_FILL_PATH);
+	paintoff &= ~VG_FILL_PATH;
+}
+
+// DestroyGradient frees a paint made by CreateLinearGradient; if it is the fill,
+// shapes are filled with it until the fill is next set
+void DestroyGradient(VGPaint paint) {
+	destroypaint(paint);
+}
+
+// FillPattern fills with an image of dimensions (w,h), red, green, blue, alpha bytes, bottom row first,
+// with its lower left corner at the origin, tiled as specified by mode. With the origin at the
+// top left, pass the top row first to keep the image upright.
+void FillPattern(int w, int h, VGubyte * data, VGTilingMode mode) {
+	VGImage img = vgCreateImage(VG_sABGR_8888, w, h, VG_IMAGE_QUALITY_BETTER);
+	vgImageSubData(img, (void *)data, w * 4, VG_sABGR_8888, 0, 0, w, h);
+	VGPaint paint = gradient();
+	vgSetParameteri(paint, VG_PAINT_TYPE, VG_PAINT_TYPE_PATTERN);
+	vgSetParameteri(paint, VG_PAINT_PATTERN_TILING_MODE, mode);
+	vgPaintPattern(paint, img);
+	vgDestroyImage(img);			   // the paint keeps the image while it uses it
+	vgSetPaint(paint, VG_FILL_PATH);
+	paintoff &= ~VG_FILL_PATH;
+}
+
+// GradientTransform sets the paint to user transform of fill gradients to the 9 values of m,
+// or the identity if m is NULL, leaving the path transform current
+void GradientTransform(const VGfloat * m) {
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_FILL_PAINT_TO_USER);
+	if (m == NULL) {
+		vgLoadIdentity();
+	} else {
+		vgLoadMatrix(m);
+	}
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_PATH_USER_TO_SURFACE);
+}
+
+// FillNone turns off filling, until a fill colour or gradient is set
+void FillNone() {
+	paintoff |= VG_FILL_PATH;
+}
+
+// StrokeNone turns off stroking, until a stroke colour is set
+void StrokeNone() {
+	paintoff |= VG_STROKE_PATH;
+}
+
+// FillRule sets the rule (VG_EVEN_ODD or VG_NON_ZERO) used to fill self-intersecting or holed paths
+void FillRule(VGFillRule rule) {
+	vgSeti(VG_FILL_RULE, rule);
+}
+
+// BlendMode sets how subsequent drawing is composited onto the surface
+void BlendMode(VGBlendMode mode) {
+	vgSeti(VG_BLEND_MODE, mode);
+}
+
+// RenderingQuality sets the antialiasing of subsequent drawing
+void RenderingQuality(VGRenderingQuality q) {
+	vgSeti(VG_RENDERING_QUALITY, q);
+}
+
+// ColorTransform scales and biases the colors of subsequent drawing;
+// values holds the red, green, blue, alpha scales followed by the biases.
+void ColorTransform(VGfloat * values) {
+	vgSetfv(VG_COLOR_TRANSFORM_VALUES, 8, values);
+	vgSeti(VG_COLOR_TRANSFORM, VG_TRUE);
+}
+
+// ColorTransformOff stops transforming colors
+void ColorTransformOff() {
+	vgSeti(VG_COLOR_TRANSFORM, VG_FALSE);
+}
+
+// GroupBegin saves the window and clears it to transparent, so that a group of shapes
+// can be drawn by itself, then composited by GroupEnd. Returns the saved window,
+// or VG_INVALID_HANDLE if it could not be saved.
+VGImage GroupBegin() {
+	int w = state->window_width, h = state->window_height;
+	VGfloat clear[4], none[4] = { 0, 0, 0, 0 };
+	VGImage saved = vgCreateImage(VG_sRGBA_8888_PRE, w, h, VG_IMAGE_QUALITY_NONANTIALIASED);
+	if (saved == VG_INVALID_HANDLE) {
+		return saved;
+	}
+	vgGetPixels(saved, 0, 0, 0, 0, w, h);
+	vgGetfv(VG_CLEAR_COLOR, 4, clear);
+	vgSetfv(VG_CLEAR_COLOR, 4, none);
+	vgClear(0, 0, w, h);
+	vgSetfv(VG_CLEAR_COLOR, 4, clear);
+	return saved;
+}
+
+// GroupEnd takes the group drawn since GroupBegin, puts back the saved window,
+// and draws the group over it with the given opacity
+void GroupEnd(VGImage saved, VGfloat opacity) {
+	if (saved == VG_INVALID_HANDLE) {
+		return;
+	}
+	int w = state->window_width, h = state->window_height;
+	VGImage layer = vgCreateImage(VG_sRGBA_8888_PRE, w, h, VG_IMAGE_QUALITY_NONANTIALIASED);
+	if (layer != VG_INVALID_HANDLE) {
+		vgGetPixels(layer, 0, 0, 0, 0, w, h);
+	}
+	vgSetPixels(0, 0, saved, 0, 0, w, h);
+	vgDestroyImage(saved);
+	if (layer == VG_INVALID_HANDLE) {
+		return;
+	}
+	// fade the layer with a color transform, keeping any set by the caller
+	VGint transform = vgGeti(VG_COLOR_TRANSFORM);
+	VGfloat values[8], fade[8] = { 1, 1, 1, opacity, 0, 0, 0, 0 };
+	vgGetfv(VG_COLOR_TRANSFORM_VALUES, 8, values);
+	vgSetfv(VG_COLOR_TRANSFORM_VALUES, 8, fade);
+	vgSeti(VG_COLOR_TRANSFORM, VG_TRUE);
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_IMAGE_USER_TO_SURFACE);
+	vgLoadIdentity();
+	vgDrawImage(layer);
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_PATH_USER_TO_SURFACE);
+	vgSetfv(VG_COLOR_TRANSFORM_VALUES, 8, values);
+	vgSeti(VG_COLOR_TRANSFORM, transform);
+	vgDestroyImage(layer);
 }
 
 // ClipRect limits the drawing area to specified rectangle
 void ClipRect(VGint x, VGint y, VGint w, VGint h) {
 	vgSeti(VG_SCISSORING, VG_TRUE);
-	VGint coords[4] = { x, y, w, h };
+	VGint coords[4] = { x, windowy(y, h), w, h };
 	vgSetiv(VG_SCISSOR_RECTS, 4, coords);
 }
 
+// Scissor limits the drawing area to the union of n rectangles, each given
+// as x, y, w, h in rects; end with ClipEnd
+void Scissor(VGint * rects, int n) {
+	VGint *r = malloc(n * 4 * sizeof(VGint));
+	int i;
+	if (r == NULL) {
+		return;
+	}
+	for (i = 0; i < n * 4; i += 4) {
+		r[i] = rects[i];
+		r[i + 1] = windowy(rects[i + 1], rects[i + 3]);
+		r[i + 2] = rects[i + 2];
+		r[i + 3] = rects[i + 3];
+	}
+	vgSeti(VG_SCISSORING, VG_TRUE);
+	vgSetiv(VG_SCISSOR_RECTS, n * 4, r);
+	free(r);
+}
+
 // ClipEnd stops limiting drawing area to specified rectangle
 void ClipEnd() {
 	vgSeti(VG_SCISSORING, VG_FALSE);
 }
 
+// Masking
+
+// drawpath renders a path, or adds its coverage to the mask between MaskBegin and MaskEnd.
+// Paint turned off by FillNone and StrokeNone is not drawn, but still adds to the mask,
+// which has no paint.
+void drawpath(VGPath path, VGbitfield flags) {
+	if (masking) {
+		vgRenderToMask(path, flags, VG_UNION_MASK);
+		return;
+	}
+	flags &= ~paintoff;
+	if (flags != 0) {
+		vgDrawPath(path, flags);
+	}
+}
+
+// MaskBegin clears the mask; shapes drawn until MaskEnd define the visible area
+void MaskBegin() {
+	vgMask(VG_INVALID_HANDLE, VG_CLEAR_MASK, 0, 0, state->window_width, state->window_height);
+	masking = 1;
+}
+
+// MaskEnd stops defining the mask, and limits subsequent drawing to its coverage
+void MaskEnd() {
+	masking = 0;
+	vgSeti(VG_MASKING, VG_TRUE);
+}
+
+// MaskDisable turns off masking
+void MaskDisable() {
+	masking = 0;
+	vgSeti(VG_MASKING, VG_FALSE);
+}
+
+// ClipBegin starts defining a clip path: shapes drawn until ClipUse make up the clip area.
+// A clip begun while another is in use is intersected with it.
+void ClipBegin() {
+	if (clipdepth > 0) {
+		if (cliplayer == VG_INVALID_HANDLE) {
+			cliplayer = vgCreateMaskLayer(state->window_width, state->window_height);
+		}
+		vgCopyMask(cliplayer, 0, 0, 0, 0, state->window_width, state->window_height);
+	}
+	MaskBegin();
+}
+
+// ClipUse limits subsequent drawing to the clip path defined since ClipBegin
+void ClipUse() {
+	MaskEnd();
+	if (clipdepth > 0) {
+		vgMask(cliplayer, VG_INTERSECT_MASK, 0, 0, state->window_width, state->window_height);
+	}
+	clipdepth++;
+}
+
+// ClipReset removes all clip paths
+void ClipReset() {
+	MaskDisable();
+	clipdepth = 0;
+	if (cliplayer != VG_INVALID_HANDLE) {
+		vgDestroyMaskLayer(cliplayer);
+		cliplayer = VG_INVALID_HANDLE;
+	}
+}
+
 // Text Functions
 
-// next_utf_char handles UTF encoding
+// next_utf_char decodes the UTF-8 character at utf8 into codepoint, returning
+// the start of the next character, or NULL at the end of the string.
+// A malformed or truncated sequence yields U+FFFD and advances one byte.
 unsigned char *next_utf8_char(unsigned char *utf8, int *codepoint) {
-	int seqlen;
-	int datalen = (int)strlen((const char *)utf8);
-	unsigned char *p = utf8;
+	int i, seqlen;
 
-	if (datalen < 1 || *utf8 == 0) {		   // End of string
+	if (*utf8 == 0) {				   // End of string
 		return NULL;
 	}
 	if (!(utf8[0] & 0x80)) {			   // 0xxxxxxx
-		*codepoint = (wchar_t) utf8[0];
+		*codepoint = (int)utf8[0];
 		seqlen = 1;
 	} else if ((utf8[0] & 0xE0) == 0xC0) {		   // 110xxxxx 
-		*codepoint = (int)(((utf8[0] & 0x1F) << 6) | (utf8[1] & 0x3F));
+		*codepoint = (int)(utf8[0] & 0x1F);
 		seqlen = 2;
 	} else if ((utf8[0] & 0xF0) == 0xE0) {		   // 1110xxxx
-		*codepoint = (int)(((utf8[0] & 0x0F) << 12) | ((utf8[1] & 0x3F) << 6) | (utf8[2] & 0x3F));
+		*codepoint = (int)(utf8[0] & 0x0F);
 		seqlen = 3;
+	} else if ((utf8[0] & 0xF8) == 0xF0) {		   // 11110xxx
+		*codepoint = (int)(utf8[0] & 0x07);
+		seqlen = 4;
 	} else {
-		return NULL;				   // No code points this high here
+		*codepoint = 0xFFFD;			   // stray continuation byte
+		return utf8 + 1;
 	}
-	p += seqlen;
-	return p;
+	for (i = 1; i < seqlen; i++) {
+		if ((utf8[i] & 0xC0) != 0x80) {		   // truncated sequence
+			*codepoint = 0xFFFD;
+			return utf8 + 1;
+		}
+		*codepoint = (*codepoint << 6) | (utf8[i] & 0x3F);
+	}
+	return utf8 + seqlen;
 }
 
-// Text renders a string of text at a specified location, size, using the specified font glyphs
+// glyphindex returns the glyph for a character in a font, or -1 if the font has none
+static int glyphindex(const Fontinfo *f, int character) {
+	if (character < 0 || character >= MAXFONTPATH) {
+		return -1;
+	}
+	return f->CharacterMap[character];
+}
+
+// kern returns the kerning adjustment, in font units, between two glyphs;
+// zero if the pair is not kerned, or either glyph is missing from the font (-1)
+static int kern(const Fontinfo *f, int left, int right) {
+	int lo = 0, hi = f->KernCount - 1;
+	if (left == -1 || right == -1) {
+		return 0;
+	}
+	while (lo <= hi) {
+		int mid = (lo + hi) / 2;
+		const int *k = &f->Kerning[mid * 3];
+		if (k[0] == left && k[1] == right) {
+			return k[2];
+		}
+		if (k[0] < left || (k[0] == left && k[1] < right)) {
+			lo = mid + 1;
+		} else {
+			hi = mid - 1;
+		}
+	}
+	return 0;
+}
+
+// KernPair returns the kerning adjustment between two characters at the specified size,
+// the amount added to the advance of a before drawing b; zero if the font has no kerning for them
+VGfloat KernPair(Fontinfo f, int a, int b, int pointsize) {
+	return pointsize * kern(&f, glyphindex(&f, a), glyphindex(&f, b)) / 65536.0f;
+}
+
+// glyphcoords returns the number of coordinates taken by a glyph outline segment;
+// the fonts use absolute moves, lines, quadratic and cubic curves, and closes
+static int glyphcoords(VGubyte segment) {
+	switch (segment) {
+	case VG_MOVE_TO_ABS:
+	case VG_LINE_TO_ABS:
+		return 2;
+	case VG_QUAD_TO_ABS:
+		return 4;
+	case VG_CUBIC_TO_ABS:
+		return 6;
+	default:
+		return 0;
+	}
+}
+
+// GlyphSegments returns the number of segments in the outline of a character's glyph,
+// setting ncoords to the number of coordinates, or returns -1 if the font has no glyph
+int GlyphSegments(Fontinfo f, int character, int *ncoords) {
+	int glyph = glyphindex(&f, character);
+	if (glyph == -1) {
+		return -1;
+	}
+	const unsigned char *instructions = &f.Instructions[f.InstructionIndices[glyph]];
+	int i, n = f.InstructionCounts[glyph];
+	*ncoords = 0;
+	for (i = 0; i < n; i++) {
+		*ncoords += glyphcoords(instructions[i]);
+	}
+	return n;
+}
+
+// GlyphOutline copies the outline of a character's glyph at the specified size, with its origin
+// on the baseline, into segments and coords, sized as given by GlyphSegments
+void GlyphOutline(Fontinfo f, int character, int pointsize, VGubyte * segments, VGfloat * coords) {
+	int glyph = glyphindex(&f, character);
+	if (glyph == -1) {
+		return;
+	}
+	const unsigned char *instructions = &f.Instructions[f.InstructionIndices[glyph]];
+	const int *p = &f.Points[f.PointIndices[glyph] * 2];
+	int i, j, nc = 0, n = f.InstructionCounts[glyph];
+	for (i = 0; i < n; i++) {
+		segments[i] = instructions[i];
+		for (j = glyphcoords(instructions[i]); j > 0; j--) {
+			coords[nc++] = pointsize * *p++ / 65536.0f;
+		}
+	}
+}
+
+// paintmatrix gets the fill paint to user transform into pm
+static void paintmatrix(VGfloat * pm) {
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_FILL_PAINT_TO_USER);
+	vgGetMatrix(pm);
+	vgSeti(VG_MATRIX_MODE, VG_MATRIX_PATH_USER_TO_SURFACE);
+}
+
+// drawglyph draws a glyph placed by mat, a scale and translation, within the text transform mm.
+// The fill paint transform pm is undone by the placement, so gradients and patterns span the
+// whole text in its user coordinates, rather than repeating in each glyph.
+static void drawglyph(VGPath glyph, const VGfloat * mm, const VGfloat * mat, const VGfloat * pm) {
+	vgLoadMatrix(mm);
+	vgMultMatrix(mat);
+	if (mat[0] != 0 && mat[4] != 0) {
+		VGfloat inv[9] = {
+			1 / mat[0], 0.0f, 0.0f,
+			0.0f, 1 / mat[4], 0.0f,
+			-mat[6] / mat[0], -mat[7] / mat[4], 1.0f
+		};
+		vgSeti(VG_MATRIX_MODE, VG_MATRIX_FILL_PAINT_TO_USER);
+		vgLoadMatrix(inv);
+		vgMultMatrix(pm);
+		vgSeti(VG_MATRIX_MODE, VG_MATRIX_PATH_USER_TO_SURFACE);
+	}
+	drawpath(glyph, VG_FILL_PATH);
+}
+
+// TextTracking renders text, adding tracking units to the advance between glyphs;
+// negative values tighten the spacing.
 // derived from http://web.archive.org/web/20070808195131/http://developer.hybrid.fi/font2openvg/renderFont.cpp.txt
-void Text(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize) {
-	VGfloat size = (VGfloat) pointsize, xx = x, mm[9];
+void TextTracking(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize, VGfloat tracking) {
+	VGfloat size = (VGfloat) pointsize, xx = x, mm[9], pm[9];
 	vgGetMatrix(mm);
-	int character;
+	paintmatrix(pm);
+	int character, prev = -1;
 	unsigned char *ss = (unsigned char *)s;
 	while ((ss = next_utf8_char(ss, &character)) != NULL) {
-		int glyph = f.CharacterMap[character];
+		int glyph = glyphindex(&f, character);
 		if (glyph == -1) {
 			continue;			   //glyph is undefined
 		}
+		xx += size * kern(&f, prev, glyph) / 65536.0f;
+		prev = glyph;
 		VGfloat mat[9] = {
 			size, 0.0f, 0.0f,
-			0.0f, size, 0.0f,
+			0.0f, topleft ? -size : size, 0.0f,
 			xx, y, 1.0f
 		};
+		drawglyph(f.Glyphs[glyph], mm, mat, pm);
+		xx += size * f.GlyphAdvances[glyph] / 65536.0f + tracking;
+	}
+	GradientTransform(pm);
+	vgLoadMatrix(mm);
+}
+
+// TextPositioned draws each of n characters with its origin at (xs[i], y);
+// characters without a glyph are skipped
+void TextPositioned(VGfloat y, const int *chars, const VGfloat * xs, int n, Fontinfo f, int pointsize) {
+	VGfloat size = (VGfloat) pointsize, mm[9], pm[9];
+	int i;
+	vgGetMatrix(mm);
+	paintmatrix(pm);
+	for (i = 0; i < n; i++) {
+		int glyph = glyphindex(&f, chars[i]);
+		if (glyph == -1) {
+			continue;
+		}
+		VGfloat mat[9] = {
+			size, 0.0f, 0.0f,
+			0.0f, topleft ? -size : size, 0.0f,
+			xs[i], y, 1.0f
+		};
+		drawglyph(f.Glyphs[glyph], mm, mat, pm);
+	}
+	GradientTransform(pm);
+	vgLoadMatrix(mm);
+}
+
+// TextOnPath renders text along a path, beginning offset units from its start,
+// each glyph centered on the path and rotated to follow it.
+// Glyphs whose centers fall beyond the end of the path are not drawn.
+void TextOnPath(VGPath path, VGfloat offset, const char *s, Fontinfo f, int pointsize) {
+	VGfloat size = (VGfloat) pointsize, d = offset, mm[9];
+	VGfloat px, py, tx, ty;
+	VGint nseg = vgGetParameteri(path, VG_PATH_NUM_SEGMENTS);
+	VGfloat length = vgPathLength(path, 0, nseg);
+	int character, prev = -1;
+	unsigned char *ss = (unsigned char *)s;
+	vgGetMatrix(mm);
+	while ((ss = next_utf8_char(ss, &character)) != NULL) {
+		int glyph = glyphindex(&f, character);
+		if (glyph == -1) {
+			continue;
+		}
+		d += size * kern(&f, prev, glyph) / 65536.0f;
+		prev = glyph;
+		VGfloat advance = size * f.GlyphAdvances[glyph] / 65536.0f;
+		VGfloat mid = d + advance / 2;
+		d += advance;
+		if (mid < 0) {
+			continue;
+		}
+		if (mid > length) {
+			break;
+		}
+		vgPointAlongPath(path, 0, nseg, mid, &px, &py, &tx, &ty);
 		vgLoadMatrix(mm);
-		vgMultMatrix(mat);
-		vgDrawPath(f.Glyphs[glyph], VG_FILL_PATH);
-		xx += size * f.GlyphAdvances[glyph] / 65536.0f;
+		vgTranslate(px, py);
+		vgRotate(atan2f(ty, tx) * 180 / M_PI);
+		vgTranslate(-advance / 2, 0);
+		vgScale(size, topleft ? -size : size);
+		drawpath(f.Glyphs[glyph], VG_FILL_PATH);
 	}
 	vgLoadMatrix(mm);
 }
 
-// TextWidth returns the width of a text string at the specified font and size.
-VGfloat TextWidth(const char *s, Fontinfo f, int pointsize) {
+// Text renders a string of text at a specified location, size, using the specified font glyphs
+void Text(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize) {
+	TextTracking(x, y, s, f, pointsize, 0);
+}
+
+// TextWidthTracking returns the width of a text string drawn with TextTracking.
+// Tracking is applied between glyphs, not after the last one.
+VGfloat TextWidthTracking(const char *s, Fontinfo f, int pointsize, VGfloat tracking) {
 	VGfloat tw = 0.0;
 	VGfloat size = (VGfloat) pointsize;
-	int character;
+	int character, n = 0, prev = -1;
 	unsigned char *ss = (unsigned char *)s;
 	while ((ss = next_utf8_char(ss, &character)) != NULL) {
-		int glyph = f.CharacterMap[character];
+		int glyph = glyphindex(&f, character);
 		if (glyph == -1) {
 			continue;			   //glyph is undefined
 		}
-		tw += size * f.GlyphAdvances[glyph] / 65536.0f;
+		tw += size * (f.GlyphAdvances[glyph] + kern(&f, prev, glyph)) / 65536.0f + tracking;
+		prev = glyph;
+		n++;
+	}
+	if (n > 0) {
+		tw -= tracking;
 	}
 	return tw;
 }
 
+// TextFitCount returns the number of leading characters of a text string, at the specified
+// font and size, whose width is at most maxwidth. Characters without a glyph take no width.
+int TextFitCount(const char *s, Fontinfo f, int pointsize, VGfloat maxwidth) {
+	VGfloat tw = 0.0;
+	VGfloat size = (VGfloat) pointsize;
+	int character, n = 0, prev = -1;
+	unsigned char *ss = (unsigned char *)s;
+	while ((ss = next_utf8_char(ss, &character)) != NULL) {
+		int glyph = glyphindex(&f, character);
+		if (glyph != -1) {
+			tw += size * (f.GlyphAdvances[glyph] + kern(&f, prev, glyph)) / 65536.0f;
+			prev = glyph;
+		}
+		if (tw > maxwidth) {
+			break;
+		}
+		n++;
+	}
+	return n;
+}
+
+// TextWidth returns the width of a text string at the specified font and size.
+VGfloat TextWidth(const char *s, Fontinfo f, int pointsize) {
+	return TextWidthTracking(s, f, pointsize, 0);
+}
+
 // TextMid draws text, centered on (x,y)
 void TextMid(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize) {
 	VGfloat tw = TextWidth(s, f, pointsize);
@@ -513,6 +1449,36 @@ void TextEnd(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize) {
 	Text(x - tw, y, s, f, pointsize);
 }
 
+// Texts draws n strings, packed one after another in text, each ending with a NUL.
+// String i is drawn at (xy[2i], xy[2i+1]) in the font fonts[i] at sizes[i],
+// beginning there if aligns[i] is 0, centered on it if 1, and ending there if 2.
+void Texts(int n, const char *text, const VGfloat * xy, Fontinfo * const *fonts, const int *sizes, const int *aligns) {
+	int i;
+	for (i = 0; i < n; i++) {
+		switch (aligns[i]) {
+		case 1:
+			TextMid(xy[2 * i], xy[2 * i + 1], text, *fonts[i], sizes[i]);
+			break;
+		case 2:
+			TextEnd(xy[2 * i], xy[2 * i + 1], text, *fonts[i], sizes[i]);
+			break;
+		default:
+			Text(xy[2 * i], xy[2 * i + 1], text, *fonts[i], sizes[i]);
+		}
+		text += strlen(text) + 1;
+	}
+}
+
+// TextAngle draws text rotated by angle degrees about (x,y), restoring the prior transform
+void TextAngle(VGfloat x, VGfloat y, const char *s, Fontinfo f, int pointsize, VGfloat angle) {
+	VGfloat mm[9];
+	vgGetMatrix(mm);
+	vgTranslate(x, y);
+	vgRotate(angle);
+	Text(0, 0, s, f, pointsize);
+	vgLoadMatrix(mm);
+}
+
 // TextHeight reports a font's height
 VGfloat TextHeight(Fontinfo f, int pointsize) {
 	return (f.font_height * pointsize) / 65536;
@@ -523,6 +1489,53 @@ VGfloat TextDepth(Fontinfo f, int pointsize) {
 	return (-f.descender_height * pointsize) / 65536;
 }
 
+// TextBounds reports the width of a text string, with the font's height and depth
+void TextBounds(const char *s, Fontinfo f, int pointsize, VGfloat * w, VGfloat * ascent, VGfloat * descent) {
+	*w = TextWidth(s, f, pointsize);
+	*ascent = TextHeight(f, pointsize);
+	*descent = TextDepth(f, pointsize);
+}
+
+// TextExtent reports the tight bounding box of the glyphs of a text string drawn at (0,0).
+// The box is empty if no glyph has an outline.
+void TextExtent(const char *s, Fontinfo f, int pointsize, VGfloat * x, VGfloat * y, VGfloat * w, VGfloat * h) {
+	VGfloat size = (VGfloat) pointsize, xx = 0, gx, gy, gw, gh;
+	VGfloat minx = 0, miny = 0, maxx = 0, maxy = 0;
+	int character, found = 0, prev = -1;
+	unsigned char *ss = (unsigned char *)s;
+	while ((ss = next_utf8_char(ss, &character)) != NULL) {
+		int glyph = glyphindex(&f, character);
+		if (glyph == -1) {
+			continue;
+		}
+		xx += size * kern(&f, prev, glyph) / 65536.0f;
+		prev = glyph;
+		vgPathBounds(f.Glyphs[glyph], &gx, &gy, &gw, &gh);
+		if (gw >= 0 && gh >= 0) {		   // empty glyphs, like space, have no bounds
+			gx = xx + gx * size;
+			gy = gy * size;
+			gw *= size;
+			gh *= size;
+			if (!found) {
+				minx = gx;
+				miny = gy;
+				maxx = gx + gw;
+				maxy = gy + gh;
+				found = 1;
+			}
+			minx = gx < minx ? gx : minx;
+			miny = gy < miny ? gy : miny;
+			maxx = gx + gw > maxx ? gx + gw : maxx;
+			maxy = gy + gh > maxy ? gy + gh : maxy;
+		}
+		xx += size * f.GlyphAdvances[glyph] / 65536.0f;
+	}
+	*x = minx;
+	*y = topleft ? -maxy : miny;		   // glyphs are drawn upright, so extend toward negative y
+	*w = maxx - minx;
+	*h = maxy - miny;
+}
+
 //
 // Shape functions
 //
@@ -538,7 +1551,7 @@ VGPath newpath() {
 void makecurve(VGubyte * segments, VGfloat * coords, VGbitfield flags) {
 	VGPath path = newpath();
 	vgAppendPathData(path, 2, segments, coords);
-	vgDrawPath(path, flags);
+	drawpath(path, flags);
 	vgDestroyPath(path);
 }
 
@@ -570,7 +1583,7 @@ void poly(VGfloat * x, VGfloat * y, VGint n, VGbitfield flag) {
 	VGPath path = newpath();
 	interleave(x, y, n, points);
 	vguPolygon(path, points, n, VG_FALSE);
-	vgDrawPath(path, flag);
+	drawpath(path, flag);
 	vgDestroyPath(path);
 }
 
@@ -584,11 +1597,21 @@ void Polyline(VGfloat * x, VGfloat * y, VGint n) {
 	poly(x, y, n, VG_STROKE_PATH);
 }
 
+// PolygonOutline makes a closed polygon with vertices at x, y arrays, outlined
+void PolygonOutline(VGfloat * x, VGfloat * y, VGint n) {
+	VGfloat points[n * 2];
+	VGPath path = newpath();
+	interleave(x, y, n, points);
+	vguPolygon(path, points, n, VG_TRUE);
+	drawpath(path, VG_STROKE_PATH);
+	vgDestroyPath(path);
+}
+
 // Rect makes a rectangle at the specified location and dimensions
 void Rect(VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
 	VGPath path = newpath();
 	vguRect(path, x, y, w, h);
-	vgDrawPath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
@@ -596,7 +1619,47 @@ void Rect(VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
 void Line(VGfloat x1, VGfloat y1, VGfloat x2, VGfloat y2) {
 	VGPath path = newpath();
 	vguLine(path, x1, y1, x2, y2);
-	vgDrawPath(path, VG_STROKE_PATH);
+	drawpath(path, VG_STROKE_PATH);
+	vgDestroyPath(path);
+}
+
+// Lines makes n separate lines as a single path; coords holds x1, y1, x2, y2 for each
+void Lines(VGfloat * coords, int n) {
+	VGubyte *segments = malloc(n * 2);
+	int i;
+	if (segments == NULL) {
+		return;
+	}
+	for (i = 0; i < n; i++) {
+		segments[i * 2] = VG_MOVE_TO_ABS;
+		segments[i * 2 + 1] = VG_LINE_TO_ABS;
+	}
+	VGPath path = newpath();
+	vgAppendPathData(path, n * 2, segments, coords);
+	drawpath(path, VG_STROKE_PATH);
+	vgDestroyPath(path);
+	free(segments);
+}
+
+// Points makes a filled circle of radius r at each of n points, as a single path
+void Points(VGfloat * x, VGfloat * y, int n, VGfloat r) {
+	int i;
+	VGPath path = newpath();
+	for (i = 0; i < n; i++) {
+		vguEllipse(path, x[i], y[i], r * 2, r * 2);
+	}
+	drawpath(path, VG_FILL_PATH);
+	vgDestroyPath(path);
+}
+
+// Dots makes a filled one unit square at each of n points, as a single path
+void Dots(VGfloat * x, VGfloat * y, int n) {
+	int i;
+	VGPath path = newpath();
+	for (i = 0; i < n; i++) {
+		vguRect(path, x[i], y[i], 1, 1);
+	}
+	drawpath(path, VG_FILL_PATH);
 	vgDestroyPath(path);
 }
 
@@ -604,15 +1667,142 @@ void Line(VGfloat x1, VGfloat y1, VGfloat x2, VGfloat y2) {
 void Roundrect(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat rw, VGfloat rh) {
 	VGPath path = newpath();
 	vguRoundRect(path, x, y, w, h, rw, rh);
-	vgDrawPath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	vgDestroyPath(path);
+}
+
+// cornerscale returns the factor by which corner radii a and b, along a side of length
+// side, are reduced so that they fit, at most 1
+static VGfloat cornerscale(VGfloat f, VGfloat side, VGfloat a, VGfloat b) {
+	if (a + b > side && side / (a + b) < f) {
+		return side / (a + b);
+	}
+	return f;
+}
+
+// RoundrectCorners makes a rectangle at the specified location and dimensions with a radius
+// for each of its top left, top right, bottom right and bottom left corners, zero making a
+// square corner. Negative radii are taken as zero, and radii too large for the sides are
+// reduced in proportion. Top and bottom follow the origin set by Origin.
+void RoundrectCorners(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat tl, VGfloat tr, VGfloat br, VGfloat bl) {
+	if (w <= 0 || h <= 0) {
+		return;
+	}
+	// radii of the corners at (x,y), (x+w,y), (x+w,y+h), (x,y+h)
+	VGfloat r[4] = { bl, br, tr, tl }, f = 1;
+	if (topleft) {
+		r[0] = tl;
+		r[1] = tr;
+		r[2] = br;
+		r[3] = bl;
+	}
+	int i;
+	for (i = 0; i < 4; i++) {
+		if (r[i] < 0) {
+			r[i] = 0;
+		}
+	}
+	f = cornerscale(f, w, r[0], r[1]);
+	f = cornerscale(f, h, r[1], r[2]);
+	f = cornerscale(f, w, r[2], r[3]);
+	f = cornerscale(f, h, r[3], r[0]);
+	for (i = 0; i < 4; i++) {
+		r[i] *= f;
+	}
+	// from the start of the bottom side, each side followed by the corner ending it
+	VGfloat ends[4][4] = {
+		{x + w - r[1], y, x + w, y + r[1]},
+		{x + w, y + h - r[2], x + w - r[2], y + h},
+		{x + r[3], y + h, x, y + h - r[3]},
+		{x, y + r[0], x + r[0], y},
+	};
+	VGubyte segments[10];
+	VGfloat coords[30];
+	int ns = 0, nc = 0;
+	segments[ns++] = VG_MOVE_TO_ABS;
+	coords[nc++] = x + r[0];
+	coords[nc++] = y;
+	for (i = 0; i < 4; i++) {
+		VGfloat cr = r[(i + 1) % 4];
+		segments[ns++] = VG_LINE_TO_ABS;
+		coords[nc++] = ends[i][0];
+		coords[nc++] = ends[i][1];
+		if (cr > 0) {
+			segments[ns++] = VG_SCCWARC_TO_ABS;
+			coords[nc++] = cr;
+			coords[nc++] = cr;
+			coords[nc++] = 0;
+			coords[nc++] = ends[i][2];
+			coords[nc++] = ends[i][3];
+		}
+	}
+	segments[ns++] = VG_CLOSE_PATH;
+	VGPath path = newpath();
+	vgAppendPathData(path, ns, segments, coords);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
+// SetCircleSegments sets the number of line segments approximating a full circle or ellipse
+// drawn by Ellipse, Circle, Arc, Pie and their outlines, or if n is 0 (the default), draws them
+// with arcs, which OpenVG flattens according to their size on the screen
+void SetCircleSegments(int n) {
+	circlesegments = n < 0 ? 0 : n;
+}
+
+// arcpath adds to a path an elliptical arc at the specified location and dimensions, of the type
+// VGU_ARC_OPEN, VGU_ARC_PIE or VGU_ARC_CHORD, as line segments if set by SetCircleSegments
+static void arcpath(VGPath path, VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat sa, VGfloat aext, VGUArcType type) {
+	if (circlesegments == 0) {
+		vguArc(path, x, y, w, h, sa, aext, type);
+		return;
+	}
+	int n = (int)ceilf(circlesegments * fabsf(aext) / 360), i, ns = 0, nc = 0;
+	if (n < 1) {
+		n = 1;
+	}
+	VGubyte *segments = malloc(n + 3);
+	VGfloat *coords = malloc((n + 2) * 2 * sizeof(VGfloat));
+	if (segments == NULL || coords == NULL) {
+		free(segments);
+		free(coords);
+		return;
+	}
+	if (type == VGU_ARC_PIE) {
+		segments[ns++] = VG_MOVE_TO_ABS;
+		coords[nc++] = x;
+		coords[nc++] = y;
+	}
+	for (i = 0; i <= n; i++) {
+		VGfloat a = (sa + aext * i / n) * M_PI / 180;
+		segments[ns] = ns == 0 ? VG_MOVE_TO_ABS : VG_LINE_TO_ABS;
+		ns++;
+		coords[nc++] = x + w / 2 * cosf(a);
+		coords[nc++] = y + h / 2 * sinf(a);
+	}
+	if (type != VGU_ARC_OPEN) {
+		segments[ns++] = VG_CLOSE_PATH;
+	}
+	vgAppendPathData(path, ns, segments, coords);
+	free(segments);
+	free(coords);
+}
+
+// ellipsepath adds to a path an ellipse at the specified location and dimensions,
+// as line segments if set by SetCircleSegments
+static void ellipsepath(VGPath path, VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
+	if (circlesegments == 0) {
+		vguEllipse(path, x, y, w, h);
+		return;
+	}
+	arcpath(path, x, y, w, h, 0, 360, VGU_ARC_CHORD);
+}
+
 // Ellipse makes an ellipse at the specified location and dimensions
 void Ellipse(VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
 	VGPath path = newpath();
-	vguEllipse(path, x, y, w, h);
-	vgDrawPath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	ellipsepath(path, x, y, w, h);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
@@ -624,21 +1814,187 @@ void Circle(VGfloat x, VGfloat y, VGfloat r) {
 // Arc makes an elliptical arc at the specified location and dimensions
 void Arc(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat sa, VGfloat aext) {
 	VGPath path = newpath();
-	vguArc(path, x, y, w, h, sa, aext, VGU_ARC_OPEN);
-	vgDrawPath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	arcpath(path, x, y, w, h, sa, aext, VGU_ARC_OPEN);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
-// Start begins the picture, clearing a rectangular region with a specified color
-void Start(int width, int height) {
-	VGfloat color[4] = { 1, 1, 1, 1 };
-	vgSetfv(VG_CLEAR_COLOR, 4, color);
+// Pie makes a pie slice centered at (x,y) with radius r, starting at angle sa, extending aext degrees.
+// Extents beyond a full circle are clamped; a zero extent, or a zero or negative radius, draws nothing.
+void Pie(VGfloat x, VGfloat y, VGfloat r, VGfloat sa, VGfloat aext) {
+	if (aext == 0 || !(r > 0)) {
+		return;
+	}
+	if (aext > 360) {
+		aext = 360;
+	}
+	if (aext < -360) {
+		aext = -360;
+	}
+	VGPath path = newpath();
+	arcpath(path, x, y, r * 2, r * 2, sa, aext, VGU_ARC_PIE);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	vgDestroyPath(path);
+}
+
+// ringarc appends a circular arc of radius r around (x,y), from angle sa through aext degrees,
+// as two half arcs so that neither is ambiguous
+static void ringarc(VGubyte * segments, VGfloat * coords, int *ns, int *nc, VGfloat x, VGfloat y, VGfloat r, VGfloat sa, VGfloat aext) {
+	VGubyte seg = aext > 0 ? VG_SCCWARC_TO_ABS : VG_SCWARC_TO_ABS;
+	int i;
+	for (i = 1; i <= 2; i++) {
+		VGfloat a = (sa + aext * i / 2) * M_PI / 180;
+		segments[(*ns)++] = seg;
+		coords[(*nc)++] = r;
+		coords[(*nc)++] = r;
+		coords[(*nc)++] = 0;
+		coords[(*nc)++] = x + r * cosf(a);
+		coords[(*nc)++] = y + r * sinf(a);
+	}
+}
+
+// Ring makes a ring segment centered at (x,y), between radii ri and ro, starting at angle sa,
+// extending aext degrees. Negative radii are taken as zero, and extents beyond a full circle are
+// clamped; a full circle makes a complete annulus.
+void Ring(VGfloat x, VGfloat y, VGfloat ri, VGfloat ro, VGfloat sa, VGfloat aext) {
+	if (ri < 0) {
+		ri = 0;
+	}
+	if (ro < 0) {
+		ro = 0;
+	}
+	if (ri > ro) {
+		VGfloat t = ri;
+		ri = ro;
+		ro = t;
+	}
+	if (aext == 0 || ro == 0) {
+		return;
+	}
+	if (aext > 360) {
+		aext = 360;
+	}
+	if (aext < -360) {
+		aext = -360;
+	}
+	int full = aext == 360 || aext == -360;
+	VGfloat a0 = sa * M_PI / 180, a1 = (sa + aext) * M_PI / 180;
+	VGubyte segments[8];
+	VGfloat coords[24];
+	int ns = 0, nc = 0;
+
+	segments[ns++] = VG_MOVE_TO_ABS;
+	coords[nc++] = x + ro * cosf(a0);
+	coords[nc++] = y + ro * sinf(a0);
+	ringarc(segments, coords, &ns, &nc, x, y, ro, sa, aext);
+	if (full) {
+		// the inner circle is a separate subpath, so the outline has no seam
+		segments[ns++] = VG_CLOSE_PATH;
+		if (ri > 0) {
+			segments[ns++] = VG_MOVE_TO_ABS;
+			coords[nc++] = x + ri * cosf(a1);
+			coords[nc++] = y + ri * sinf(a1);
+			ringarc(segments, coords, &ns, &nc, x, y, ri, sa + aext, -aext);
+			segments[ns++] = VG_CLOSE_PATH;
+		}
+	} else if (ri > 0) {
+		segments[ns++] = VG_LINE_TO_ABS;
+		coords[nc++] = x + ri * cosf(a1);
+		coords[nc++] = y + ri * sinf(a1);
+		ringarc(segments, coords, &ns, &nc, x, y, ri, sa + aext, -aext);
+		segments[ns++] = VG_CLOSE_PATH;
+	} else {
+		segments[ns++] = VG_LINE_TO_ABS;
+		coords[nc++] = x;
+		coords[nc++] = y;
+		segments[ns++] = VG_CLOSE_PATH;
+	}
+	VGPath path = newpath();
+	vgAppendPathData(path, ns, segments, coords);
+	drawpath(path, VG_FILL_PATH | VG_STROKE_PATH);
+	vgDestroyPath(path);
+}
+
+// NewPath creates an empty path that can be appended to and drawn repeatedly
+VGPath NewPath() {
+	return vgCreatePath(VG_PATH_FORMAT_STANDARD, VG_PATH_DATATYPE_F, 1.0f, 0.0f, 0, 0, VG_PATH_CAPABILITY_ALL);
+}
+
+// PathAppend adds n segments and their coordinates to a path
+void PathAppend(VGPath path, int n, VGubyte * segments, VGfloat * coords) {
+	vgAppendPathData(path, n, segments, coords);
+}
+
+// PathDraw renders a path, filled and/or stroked
+void PathDraw(VGPath path, int fill, int stroke) {
+	VGbitfield flags = 0;
+	if (fill) {
+		flags |= VG_FILL_PATH;
+	}
+	if (stroke) {
+		flags |= VG_STROKE_PATH;
+	}
+	if (flags) {
+		drawpath(path, flags);
+	}
+}
+
+// PathDestroy frees a path
+void PathDestroy(VGPath path) {
+	vgDestroyPath(path);
+}
+
+// DrawSegments draws n segments and their coordinates as one path, filled and/or stroked
+void DrawSegments(int n, VGubyte * segments, VGfloat * coords, int fill, int stroke) {
+	VGPath path = newpath();
+	vgAppendPathData(path, n, segments, coords);
+	PathDraw(path, fill, stroke);
+	vgDestroyPath(path);
+}
+
+// startcolor begins the picture, clearing a rectangular region with a specified color
+static void startcolor(int width, int height, VGfloat clear[4]) {
+	VGfloat color[4] = { 0, 0, 0, 1 };
+	vgSetfv(VG_CLEAR_COLOR, 4, clear);
 	vgClear(0, 0, width, height);
-	color[0] = 0, color[1] = 0, color[2] = 0;
 	setfill(color);
 	setstroke(color);
 	StrokeWidth(0);
 	vgLoadIdentity();
+	if (topleft) {
+		vgTranslate(0, height);
+		vgScale(1, -1);
+	}
+}
+
+// Start begins the picture, clearing a rectangular region to white
+void Start(int width, int height) {
+	VGfloat white[4] = { 1, 1, 1, 1 };
+	startcolor(width, height, white);
+}
+
+// StartRGB begins the picture, clearing a rectangular region to a colour with alpha
+void StartRGB(int width, int height, unsigned int r, unsigned int g, unsigned int b, VGfloat a) {
+	VGfloat colour[4];
+	RGBA(r, g, b, a, colour);
+	startcolor(width, height, colour);
+}
+
+// Origin sets the origin of coordinates used from the next Start: the lower left, y increasing up
+// (the default), or if topleft is non-zero, the top left, y increasing down. Text and images are
+// kept upright, and window coordinates, as used by ClipRect and Scissor, follow the origin.
+void Origin(int tl) {
+	topleft = tl;
+}
+
+// Flush has OpenVG begin drawing everything requested so far, without waiting for it
+void Flush() {
+	vgFlush();
+}
+
+// RenderFinish waits until everything requested so far has been drawn, without presenting it
+void RenderFinish() {
+	vgFinish();
 }
 
 // End checks for errors, and renders to the display
@@ -681,15 +2037,61 @@ void BackgroundRGB(unsigned int r, unsigned int g, unsigned int b, VGfloat a) {
 	vgClear(0, 0, state->window_width, state->window_height);
 }
 
+// fillwindow covers the whole window with the current fill, ignoring any transformation
+static void fillwindow() {
+	VGfloat mm[9];
+	vgGetMatrix(mm);
+	vgLoadIdentity();
+	VGPath path = newpath();
+	vguRect(path, 0, 0, state->window_width, state->window_height);
+	drawpath(path, VG_FILL_PATH);
+	vgDestroyPath(path);
+	vgLoadMatrix(mm);
+}
+
+// BackgroundLinearGradient fills the window with a top to bottom gradient.
+// The fill is left set to the gradient.
+void BackgroundLinearGradient(VGfloat * stops, int ns) {
+	FillLinearGradient(0, state->window_height, 0, 0, stops, ns);
+	fillwindow();
+}
+
+// BackgroundRadialGradient fills the window with a gradient centered on the window,
+// reaching its last stop at the corners. The fill is left set to the gradient.
+void BackgroundRadialGradient(VGfloat * stops, int ns) {
+	VGfloat cx = state->window_width / 2.0f, cy = state->window_height / 2.0f;
+	FillRadialGradient(cx, cy, cx, cy, sqrtf(cx * cx + cy * cy), stops, ns);
+	fillwindow();
+}
+
+// ClearColor sets the colour used by WindowClear and AreaClear, without clearing
+void ClearColor(unsigned int r, unsigned int g, unsigned int b, VGfloat a) {
+	VGfloat colour[4];
+	RGBA(r, g, b, a, colour);
+	vgSetfv(VG_CLEAR_COLOR, 4, colour);
+}
+
 // WindowClear clears the window to previously set background colour
 void WindowClear() {
 	vgClear(0, 0, state->window_width, state->window_height);
 }
 
 // AreaClear clears a given rectangle in window coordinates (not affected by
-// transformations)
-void AreaClear(unsigned int x, unsigned int y, unsigned int w, unsigned int h) {
-	vgClear(x, y, w, h);
+// transformations) to the clear colour. (x,y) is the top left corner, measured down
+// from the top of the window, whatever the origin. Parts outside the window are ignored.
+void AreaClear(int x, int y, int w, int h) {
+	vgClear(x, (VGint) state->window_height - y - h, w, h);
+}
+
+// GetError returns, and clears, the oldest error recorded by OpenVG
+VGErrorCode GetError() {
+	return vgGetError();
+}
+
+// SwapInterval sets the minimum number of video frames between buffer swaps;
+// 0 swaps immediately, 1 waits for vsync
+void SwapInterval(int n) {
+	eglSwapInterval(state->display, n);
 }
 
 // WindowOpacity sets the  window opacity
@@ -697,11 +2099,26 @@ void WindowOpacity(unsigned int a) {
 	dispmanChangeWindowOpacity(state, a);
 }
 
+// GetWindowOpacity returns the window opacity, 0 (transparent) to 255 (opaque)
+unsigned int GetWindowOpacity() {
+	return state->window_alpha;
+}
+
 // WindowPosition moves the window to given position
 void WindowPosition(int x, int y) {
 	dispmanMoveWindow(state, x, y);
 }
 
+// WindowLayer moves the window to a dispmanx layer, keeping its position and opacity
+void WindowLayer(int layer) {
+	dispmanChangeWindowLayer(state, layer);
+}
+
+// GetWindowLayer returns the window's dispmanx layer
+int GetWindowLayer() {
+	return state->window_layer;
+}
+
 // Outlined shapes
 // Hollow shapes -because filling still happens even with a fill of 0,0,0,0
 // unlike where using a strokewidth of 0 disables the stroke.
@@ -725,23 +2142,41 @@ void QbezierOutline(VGfloat sx, VGfloat sy, VGfloat cx, VGfloat cy, VGfloat ex,
 void RectOutline(VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
 	VGPath path = newpath();
 	vguRect(path, x, y, w, h);
-	vgDrawPath(path, VG_STROKE_PATH);
+	drawpath(path, VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
+// RectDashed makes a rectangle at the specified location and dimensions, outlined with
+// the n dash and gap lengths in pattern, beginning phase along it; the dash in effect is kept
+void RectDashed(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat * pattern, int n, VGfloat phase) {
+	VGfloat savedphase = vgGetf(VG_STROKE_DASH_PHASE), *saved = NULL;
+	VGint nsaved = vgGetVectorSize(VG_STROKE_DASH_PATTERN);
+	if (nsaved > 0 && (saved = malloc(nsaved * sizeof(VGfloat))) != NULL) {
+		vgGetfv(VG_STROKE_DASH_PATTERN, nsaved, saved);
+	} else {
+		nsaved = 0;
+	}
+	vgSetfv(VG_STROKE_DASH_PATTERN, n, pattern);
+	vgSetf(VG_STROKE_DASH_PHASE, phase);
+	RectOutline(x, y, w, h);
+	vgSetfv(VG_STROKE_DASH_PATTERN, nsaved, saved);
+	vgSetf(VG_STROKE_DASH_PHASE, savedphase);
+	free(saved);
+}
+
 // RoundrectOutline  makes an rounded rectangle at the specified location and dimensions, outlined 
 void RoundrectOutline(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat rw, VGfloat rh) {
 	VGPath path = newpath();
 	vguRoundRect(path, x, y, w, h, rw, rh);
-	vgDrawPath(path, VG_STROKE_PATH);
+	drawpath(path, VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
 // EllipseOutline makes an ellipse at the specified location and dimensions, outlined
 void EllipseOutline(VGfloat x, VGfloat y, VGfloat w, VGfloat h) {
 	VGPath path = newpath();
-	vguEllipse(path, x, y, w, h);
-	vgDrawPath(path, VG_STROKE_PATH);
+	ellipsepath(path, x, y, w, h);
+	drawpath(path, VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
 
@@ -753,7 +2188,7 @@ void CircleOutline(VGfloat x, VGfloat y, VGfloat r) {
 // ArcOutline makes an elliptical arc at the specified location and dimensions, outlined
 void ArcOutline(VGfloat x, VGfloat y, VGfloat w, VGfloat h, VGfloat sa, VGfloat aext) {
 	VGPath path = newpath();
-	vguArc(path, x, y, w, h, sa, aext, VGU_ARC_OPEN);
-	vgDrawPath(path, VG_STROKE_PATH);
+	arcpath(path, x, y, w, h, sa, aext, VGU_ARC_OPEN);
+	drawpath(path, VG_STROKE_PATH);
 	vgDestroyPath(path);
 }
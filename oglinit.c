@@ -3,6 +3,13 @@
 #include <bcm_host.h>
 #include <assert.h>
 
+// attributes changed by vc_dispmanx_element_change_attributes; without any,
+// it changes them all, resetting the layer and opacity
+#define CHANGE_LAYER	(1 << 0)
+#define CHANGE_OPACITY	(1 << 1)
+#define CHANGE_DEST_RECT	(1 << 2)
+#define CHANGE_SRC_RECT	(1 << 3)
+
 // setWindowParams sets the window's position, adjusting if need be to
 // prevent it from going fully off screen. Also sets the dispman rects
 // for displaying.
@@ -90,6 +97,7 @@ void oglinit(STATE_T * state) {
 		EGL_GREEN_SIZE, 8,
 		EGL_BLUE_SIZE, 8,
 		EGL_ALPHA_SIZE, 8,
+		EGL_ALPHA_MASK_SIZE, 8,
 		EGL_SURFACE_TYPE, EGL_WINDOW_BIT,
 		EGL_NONE
 	};
@@ -116,7 +124,7 @@ void oglinit(STATE_T * state) {
 	assert(state->context != EGL_NO_CONTEXT);
 
 	// create an EGL window surface
-	success = graphics_get_display_size(0 /* LCD */ , &state->screen_width,
+	success = graphics_get_display_size(state->display_id, &state->screen_width,
 					    &state->screen_height);
 	assert(success >= 0);
 
@@ -129,14 +137,15 @@ void oglinit(STATE_T * state) {
 	// set up the dispman rects
 	setWindowParams(state, state->window_x, state->window_y, &src_rect, &dst_rect);
 
-	dispman_display = vc_dispmanx_display_open(0 /* LCD */ );
+	dispman_display = vc_dispmanx_display_open(state->display_id);
 	dispman_update = vc_dispmanx_update_start(0);
 
-	dispman_element = vc_dispmanx_element_add(dispman_update, dispman_display, 0 /*layer */ , &dst_rect, 0 /*src */ ,
+	dispman_element = vc_dispmanx_element_add(dispman_update, dispman_display, state->window_layer, &dst_rect, 0 /*src */ ,
 						  &src_rect, DISPMANX_PROTECTION_NONE, &alpha, 0 /*clamp */ ,
 						  0 /*transform */ );
 
 	state->element = dispman_element;
+	state->window_alpha = alpha.opacity;
 	nativewindow.element = dispman_element;
 	nativewindow.width = state->window_width;
 	nativewindow.height = state->window_height;
@@ -154,6 +163,111 @@ void oglinit(STATE_T * state) {
 	assert(EGL_FALSE != result);
 }
 
+// pbufferinit sets the display, OpenVG context and an offscreen pbuffer surface
+// of state->window_width by state->window_height, for rendering without a display.
+// Returns 0 on success, -1 on failure.
+int pbufferinit(STATE_T * state) {
+	EGLint num_config;
+	EGLConfig config;
+
+	static const EGLint attribute_list[] = {
+		EGL_RED_SIZE, 8,
+		EGL_GREEN_SIZE, 8,
+		EGL_BLUE_SIZE, 8,
+		EGL_ALPHA_SIZE, 8,
+		EGL_ALPHA_MASK_SIZE, 8,
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_NONE
+	};
+	EGLint pbuffer_attributes[] = {
+		EGL_WIDTH, state->window_width,
+		EGL_HEIGHT, state->window_height,
+		EGL_NONE
+	};
+
+	state->display = eglGetDisplay(EGL_DEFAULT_DISPLAY);
+	if (state->display == EGL_NO_DISPLAY) {
+		return -1;
+	}
+	if (eglInitialize(state->display, NULL, NULL) == EGL_FALSE) {
+		return -1;
+	}
+	eglBindAPI(EGL_OPENVG_API);
+	if (eglChooseConfig(state->display, attribute_list, &config, 1, &num_config) == EGL_FALSE || num_config < 1) {
+		eglTerminate(state->display);
+		return -1;
+	}
+	state->context = eglCreateContext(state->display, config, EGL_NO_CONTEXT, NULL);
+	if (state->context == EGL_NO_CONTEXT) {
+		eglTerminate(state->display);
+		return -1;
+	}
+	state->surface = eglCreatePbufferSurface(state->display, config, pbuffer_attributes);
+	if (state->surface == EGL_NO_SURFACE) {
+		eglDestroyContext(state->display, state->context);
+		eglTerminate(state->display);
+		return -1;
+	}
+	if (eglMakeCurrent(state->display, state->surface, state->surface, state->context) == EGL_FALSE) {
+		eglDestroySurface(state->display, state->surface);
+		eglDestroyContext(state->display, state->context);
+		eglTerminate(state->display);
+		return -1;
+	}
+	return 0;
+}
+
+// sharedinit makes an OpenVG context sharing images and paths with state's context,
+// current on the calling thread with a 1x1 pbuffer surface, so another thread can make
+// images for the main one. Returns 0 on success, -1 on failure.
+int sharedinit(STATE_T * state, EGLContext * context, EGLSurface * surface) {
+	EGLint num_config;
+	EGLConfig config;
+
+	static const EGLint attribute_list[] = {
+		EGL_RED_SIZE, 8,
+		EGL_GREEN_SIZE, 8,
+		EGL_BLUE_SIZE, 8,
+		EGL_ALPHA_SIZE, 8,
+		EGL_SURFACE_TYPE, EGL_PBUFFER_BIT,
+		EGL_NONE
+	};
+	static const EGLint pbuffer_attributes[] = {
+		EGL_WIDTH, 1,
+		EGL_HEIGHT, 1,
+		EGL_NONE
+	};
+
+	// the API is bound per thread
+	eglBindAPI(EGL_OPENVG_API);
+	if (eglChooseConfig(state->display, attribute_list, &config, 1, &num_config) == EGL_FALSE || num_config < 1) {
+		return -1;
+	}
+	*context = eglCreateContext(state->display, config, state->context, NULL);
+	if (*context == EGL_NO_CONTEXT) {
+		return -1;
+	}
+	*surface = eglCreatePbufferSurface(state->display, config, pbuffer_attributes);
+	if (*surface == EGL_NO_SURFACE) {
+		eglDestroyContext(state->display, *context);
+		return -1;
+	}
+	if (eglMakeCurrent(state->display, *surface, *surface, *context) == EGL_FALSE) {
+		eglDestroySurface(state->display, *surface);
+		eglDestroyContext(state->display, *context);
+		return -1;
+	}
+	return 0;
+}
+
+// shareddestroy releases a context made by sharedinit, on the thread it is current on
+void shareddestroy(STATE_T * state, EGLContext context, EGLSurface surface) {
+	eglMakeCurrent(state->display, EGL_NO_SURFACE, EGL_NO_SURFACE, EGL_NO_CONTEXT);
+	eglDestroySurface(state->display, surface);
+	eglDestroyContext(state->display, context);
+	eglReleaseThread();
+}
+
 // dispmanMoveWindow repositions the openVG window to given coords
 // -ve coords are allowed upto (1-width,1-height),
 // max (screen_width-1,screen_height-1). i.e. at least one pixel must be
@@ -164,7 +278,8 @@ void dispmanMoveWindow(STATE_T * state, int x, int y) {
 
 	setWindowParams(state, x, y, &src_rect, &dst_rect);
 	dispman_update = vc_dispmanx_update_start(0);
-	vc_dispmanx_element_change_attributes(dispman_update, state->element, 0, 0, 0, &dst_rect, &src_rect, 0, DISPMANX_NO_ROTATE);
+	vc_dispmanx_element_change_attributes(dispman_update, state->element, CHANGE_DEST_RECT | CHANGE_SRC_RECT, 0, 0, &dst_rect, &src_rect, 0,
+					      DISPMANX_NO_ROTATE);
 	vc_dispmanx_update_submit_sync(dispman_update);
 }
 
@@ -174,10 +289,21 @@ void dispmanChangeWindowOpacity(STATE_T * state, uint32_t alpha) {
 	DISPMANX_UPDATE_HANDLE_T dispman_update;
 
 	if (alpha > 255)
-		alpha = 235;
+		alpha = 255;
+	state->window_alpha = alpha;
+
+	dispman_update = vc_dispmanx_update_start(0);
+	vc_dispmanx_element_change_attributes(dispman_update, state->element, CHANGE_OPACITY, 0, alpha, 0, 0, 0, DISPMANX_NO_ROTATE);
+	vc_dispmanx_update_submit_sync(dispman_update);
+}
+
+// dispmanChangeWindowLayer moves the window to a dispman layer,
+// above windows on lower layers and below those on higher ones
+void dispmanChangeWindowLayer(STATE_T * state, int layer) {
+	DISPMANX_UPDATE_HANDLE_T dispman_update;
 
+	state->window_layer = layer;
 	dispman_update = vc_dispmanx_update_start(0);
-	// The 1<<1 below means update the alpha value
-	vc_dispmanx_element_change_attributes(dispman_update, state->element, 1 << 1, 0, alpha, 0, 0, 0, DISPMANX_NO_ROTATE);
+	vc_dispmanx_element_change_attributes(dispman_update, state->element, CHANGE_LAYER, layer, 0, 0, 0, 0, DISPMANX_NO_ROTATE);
 	vc_dispmanx_update_submit_sync(dispman_update);
 }
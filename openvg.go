@@ -3,7 +3,7 @@ package openvg
 
 /*
 #cgo CFLAGS:   -I/opt/vc/include -I/opt/vc/include/interface/vmcs_host/linux -I/opt/vc/include/interface/vcos/pthreads
-#cgo LDFLAGS:  -L/opt/vc/lib -lGLESv2 -lEGL -lbcm_host -ljpeg
+#cgo LDFLAGS:  -L/opt/vc/lib -lGLESv2 -lEGL -lbcm_host -ljpeg -lm
 #include "VG/openvg.h"
 #include "VG/vgu.h"
 #include "EGL/egl.h"
@@ -13,14 +13,20 @@ package openvg
 */
 import "C"
 import (
+	"bytes"
 	"fmt"
 	"image"
 	_ "image/gif"
-	_ "image/jpeg"
+	"image/jpeg"
 	_ "image/png"
+	"log"
+	"math"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 	"unsafe"
 	"image/color"
 )
@@ -29,13 +35,26 @@ import (
 type VGfloat C.VGfloat
 type VGint C.VGint
 
-// Offcolor defines the offset, color and alpha values used in gradients
-// the Offset ranges from 0..1, colors as RGB triples, alpha ranges from 0..1
+// Offcolor defines the offset, color and alpha values used in gradients.
+// The Offset is a fraction of the way along the gradient, 0..1, not a distance in pixels.
+// The color's red, green, blue and alpha range from 0..255, like the alpha of a Start color;
+// unlike the usual color.RGBA convention they are not premultiplied by the alpha,
+// so {255, 0, 0, 128} is half transparent pure red. NewOffcolor checks the offset.
 type Offcolor struct {
 	Offset VGfloat
 	color.RGBA
 }
 
+// NewOffcolor returns the gradient stop of color c at offset, which is clamped
+// to 0..1 with a logged warning if it is outside, as when given in pixels
+func NewOffcolor(offset VGfloat, c color.RGBA) Offcolor {
+	if offset < 0 || offset > 1 {
+		log.Printf("openvg: gradient offset %g is outside 0..1; clamped", offset)
+		offset = clamp01(offset)
+	}
+	return Offcolor{offset, c}
+}
+
 func UnwrapRGBA(rgba color.RGBA) (r, g, b uint8, a VGfloat) {
 	return rgba.R, rgba.G, rgba.B, VGfloat(rgba.A) / 255.0
 }
@@ -45,6 +64,130 @@ func UnwrapRGB(rgba color.RGBA) (r, g, b uint8) {
 }
 
 
+// InterpolateColor returns the color a fraction t (0..1) of the way from a to b,
+// interpolating each of red, green, blue and alpha linearly
+func InterpolateColor(a, b color.RGBA, t VGfloat) color.RGBA {
+	if t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+	mix := func(x, y uint8) uint8 {
+		return uint8(VGfloat(x) + (VGfloat(y)-VGfloat(x))*t + 0.5)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// SampleRamp returns the color of a gradient ramp at offset t.
+// Offsets before the first stop or after the last take that stop's color.
+func SampleRamp(ramp []Offcolor, t VGfloat) color.RGBA {
+	if len(ramp) == 0 {
+		return color.RGBA{}
+	}
+	r := make([]Offcolor, len(ramp))
+	copy(r, ramp)
+	sort.SliceStable(r, func(i, j int) bool { return r[i].Offset < r[j].Offset })
+	if t <= r[0].Offset {
+		return r[0].RGBA
+	}
+	for i := 1; i < len(r); i++ {
+		if t <= r[i].Offset {
+			span := r[i].Offset - r[i-1].Offset
+			if span == 0 {
+				return r[i].RGBA
+			}
+			return InterpolateColor(r[i-1].RGBA, r[i].RGBA, (t-r[i-1].Offset)/span)
+		}
+	}
+	return r[len(r)-1].RGBA
+}
+
+// huergb makes a color from hue (degrees), chroma and the amount m added to each component
+func huergb(h, c, m VGfloat) color.RGBA {
+	h = VGfloat(math.Mod(float64(h), 360))
+	if h < 0 {
+		h += 360
+	}
+	x := c * VGfloat(1-math.Abs(math.Mod(float64(h/60), 2)-1))
+	var r, g, b VGfloat
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	scale := func(v VGfloat) uint8 {
+		return uint8((v+m)*255 + 0.5)
+	}
+	return color.RGBA{scale(r), scale(g), scale(b), 255}
+}
+
+// clamp01 limits v to the range 0..1
+func clamp01(v VGfloat) VGfloat {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// minint returns the lesser of a and b
+func minint(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxint returns the greater of a and b
+func maxint(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// minfloat returns the lesser of a and b
+func minfloat(a, b VGfloat) VGfloat {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// maxfloat returns the greater of a and b
+func maxfloat(a, b VGfloat) VGfloat {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// HSL returns the color with hue h (degrees), saturation s and lightness l (0..1)
+func HSL(h, s, l VGfloat) color.RGBA {
+	s, l = clamp01(s), clamp01(l)
+	c := (1 - VGfloat(math.Abs(float64(2*l-1)))) * s
+	return huergb(h, c, l-c/2)
+}
+
+// HSV returns the color with hue h (degrees), saturation s and value v (0..1)
+func HSV(h, s, v VGfloat) color.RGBA {
+	s, v = clamp01(s), clamp01(v)
+	c := v * s
+	return huergb(h, c, v-c)
+}
+
 // colornames maps SVG color names to RGB triples.
 var colornames = map[string]color.RGBA{
 	"aliceblue":            {240, 248, 255, 255},
@@ -196,52 +339,251 @@ var colornames = map[string]color.RGBA{
 	"yellowgreen":          {154, 205, 50, 255},
 }
 
-// Init initializes the graphics subsystem
+// winwidth and winheight are the window dimensions, set at initialization
+var winwidth, winheight int
+
+// initialized reports whether the graphics subsystem is ready for drawing
+var initialized bool
+
+// renderthread is the thread that initialized the graphics subsystem.
+// OpenVG and EGL state belong to it, so all drawing must happen there.
+var renderthread int
+
+// threadcheck is whether each call checks that it is made on renderthread, as set by SetThreadCheck
+var threadcheck = true
+
+// SetThreadCheck sets whether each drawing call checks that it is made on the goroutine that
+// called Init, panicking if not. The check, on by default, asks the system for the thread,
+// costing a system call per drawing call, measured by go-client/batchbench; a program known
+// to draw from one goroutine may turn it off for speed, at the risk of crashing in C if not.
+func SetThreadCheck(on bool) {
+	threadcheck = on
+}
+
+// checkinit panics with an explanation, rather than crashing in C,
+// if the graphics subsystem has not been initialized, or is used from
+// a thread other than the one that initialized it; then it draws any batched
+// shapes, and passes any error to the error hook
+func checkinit() {
+	checkthread()
+	if len(pending.segments) > 0 {
+		flushbatch()
+	}
+	if errorhook != nil {
+		checkerror()
+	}
+}
+
+// checkthread panics if the graphics subsystem has not been initialized,
+// or, unless turned off by SetThreadCheck, is used from a thread other than the one that initialized it
+func checkthread() {
+	if !initialized {
+		panic("openvg: Init must be called before drawing")
+	}
+	if threadcheck && syscall.Gettid() != renderthread {
+		panic("openvg: drawing must be done on the goroutine that called Init")
+	}
+}
+
+// setinit records that the graphics subsystem is ready on the calling thread
+func setinit(w, h int) {
+	winwidth, winheight = w, h
+	renderthread = syscall.Gettid()
+	initialized = true
+}
+
+// Init initializes the graphics subsystem, locking the calling goroutine to its thread.
+// All drawing must be done from that goroutine; calls from others panic.
 func Init() (int, int) {
 	runtime.LockOSThread()
 	var rh, rw C.int
 	C.init(&rw, &rh)
-	return int(rw), int(rh)
+	setinit(int(rw), int(rh))
+	return winwidth, winheight
+}
+
+// InitOffscreen initializes the graphics subsystem to draw to an offscreen
+// surface of the specified dimensions rather than the display, for example
+// to render images without a connected screen. The drawing functions work
+// unchanged; Finish releases the surface.
+func InitOffscreen(w, h int) (int, int, error) {
+	runtime.LockOSThread()
+	if C.initOffscreen(C.int(w), C.int(h)) != 0 {
+		runtime.UnlockOSThread()
+		return 0, 0, fmt.Errorf("openvg: unable to create a %dx%d offscreen surface", w, h)
+	}
+	setinit(w, h)
+	return w, h, nil
+}
+
+// DisplayInfo describes a display output
+type DisplayInfo struct {
+	ID            int    // dispmanx display id, as passed to InitDisplay
+	Name          string // kind of output
+	Width, Height int    // resolution
+}
+
+// displaynames names the dispmanx display ids
+var displaynames = []string{"LCD", "auxiliary LCD", "HDMI", "composite", "forced LCD", "forced TV", "forced other", "HDMI 1"}
+
+// Displays returns the connected display outputs, with their resolutions.
+// It may be called before Init.
+func Displays() []DisplayInfo {
+	var displays []DisplayInfo
+	for id, name := range displaynames {
+		var w, h C.int
+		if C.DisplaySize(C.int(id), &w, &h) == 0 {
+			displays = append(displays, DisplayInfo{ID: id, Name: name, Width: int(w), Height: int(h)})
+		}
+	}
+	return displays
+}
+
+// InitDisplay initializes the graphics subsystem like Init, on the display output id,
+// as listed by Displays, rather than the default display
+func InitDisplay(id int) (int, int, error) {
+	var rw, rh C.int
+	if id < 0 || C.DisplaySize(C.int(id), &rw, &rh) != 0 {
+		return 0, 0, fmt.Errorf("openvg: no display %d", id)
+	}
+	runtime.LockOSThread()
+	C.initDisplay(C.int(id), &rw, &rh)
+	setinit(int(rw), int(rh))
+	return winwidth, winheight, nil
 }
 
-// InitWidowSize initialized the graphics subsystem with specified dimensions
+// InitWidowSize initialized the graphics subsystem with specified dimensions.
+// Called before Init, it makes the window cover only the w by h region with its top left
+// at (x,y) on the display, in screen pixels, leaving the rest of the display visible.
 func InitWindowSize(x, y, w, h int) {
 	C.initWindowSize(C.int(x), C.int(y), C.uint(w), C.uint(h))
 }
 
+// InitWindowLayer sets the display layer of the window, when called before Init;
+// without it the window is on layer 0. See WindowLayer.
+func InitWindowLayer(layer int) {
+	C.initWindowLayer(C.int(layer))
+}
+
+// WindowSize returns the dimensions of the window, as set by Init
+// (including any size requested by InitWindowSize) or InitOffscreen
+func WindowSize() (w, h int) {
+	return winwidth, winheight
+}
+
+// SetClearColor sets the color used by WindowClear and AreaClear, without clearing.
+// Start and the Background functions also set the clear color, so call it after them.
+func SetClearColor(r, g, b uint8, alpha VGfloat) {
+	checkinit()
+	C.ClearColor(C.uint(r), C.uint(g), C.uint(b), C.VGfloat(alpha))
+}
+
 // WindowClear clears the window to previously set background color
 func WindowClear() {
+	checkinit()
 	C.WindowClear()
 }
 
-// WindowPostion places a window
+// WindowPostion places a window with its top left at (x,y) on the display, in screen pixels,
+// keeping its layer and opacity. It may be partly off the screen, but not wholly.
 func WindowPosition(x, y int) {
+	checkinit()
 	C.WindowPosition(C.int(x), C.int(y))
 }
 
-// WindowOpacity sets the window's opacity
+// WindowLayer moves the window to a display layer, keeping its position and opacity.
+// Windows on higher layers are shown over those on lower ones, blended by their opacity,
+// so that several programs can share a display, each window covering only its own region;
+// give each its own layer, as the order of windows on the same layer is undefined.
+func WindowLayer(layer int) {
+	checkinit()
+	C.WindowLayer(C.int(layer))
+}
+
+// GetWindowLayer returns the window's display layer
+func GetWindowLayer() int {
+	checkinit()
+	return int(C.GetWindowLayer())
+}
+
+// WindowOpacity sets the window's opacity, from 0 (transparent) to 255 (opaque);
+// larger values are taken as 255
 func WindowOpacity(a uint) {
+	checkinit()
+	if a > 255 {
+		a = 255
+	}
 	C.WindowOpacity(C.uint(a))
 }
 
-// AreaClear clears a given rectangle in window coordinates
+// GetWindowOpacity returns the window's opacity, from 0 (transparent) to 255 (opaque)
+func GetWindowOpacity() uint {
+	checkinit()
+	return uint(C.GetWindowOpacity())
+}
+
+// FadeWindow changes the window's opacity steadily from its current value to target
+// (clamped to 255) over the duration d, returning when it is done. Each step waits for
+// the display to update, so the fade is as smooth as the display allows. Like all drawing,
+// it must be called from the goroutine that called Init, and nothing is drawn meanwhile.
+func FadeWindow(target uint, d time.Duration) {
+	checkinit()
+	if target > 255 {
+		target = 255
+	}
+	from := float64(C.GetWindowOpacity())
+	begin := time.Now()
+	for t := time.Since(begin); t < d; t = time.Since(begin) {
+		a := from + (float64(target)-from)*float64(t)/float64(d)
+		C.WindowOpacity(C.uint(a + 0.5))
+	}
+	C.WindowOpacity(C.uint(target))
+}
+
+// AreaClear clears a given rectangle in window coordinates to the clear color
+// (as set by SetClearColor, or the last background), ignoring any transformation.
+// (x,y) is the top left corner, with y measured down from the top of the window,
+// whatever the origin set by SetOrigin; parts outside the window are ignored.
 func AreaClear(x, y, w, h int) {
-	C.AreaClear(C.uint(x), C.uint(y), C.uint(w), C.uint(h))
+	checkinit()
+	if w <= 0 || h <= 0 {
+		return
+	}
+	C.AreaClear(C.int(x), C.int(y), C.int(w), C.int(h))
 }
 
-// Finish shuts down the graphics subsystem
-func Finish() {
+// Shutdown shuts down the graphics subsystem
+func Shutdown() {
+	checkinit()
 	C.finish()
+	initialized = false
 	runtime.UnlockOSThread()
 }
 
+// Finish is Shutdown, under the name used before RenderFinish was added
+func Finish() {
+	Shutdown()
+}
+
+// RenderFinish waits until everything drawn so far is complete, without presenting it,
+// so that Snapshot, PixelAt and the like read back all of it, as when debugging a frame.
+// Shapes accumulated by Batch are drawn first.
+func RenderFinish() {
+	checkinit()
+	C.RenderFinish()
+}
+
 // Background clears the screen with the specified solid background color using RGB triples
 func Background(r, g, b uint8) {
+	checkinit()
+	svgbackground(r, g, b, 1)
 	C.Background(C.uint(r), C.uint(g), C.uint(b))
 }
 
 // BackgroundRGB clears the screen with the specified background color using a RGBA quad
 func BackgroundRGB(r, g, b uint8, alpha VGfloat) {
+	checkinit()
+	svgbackground(r, g, b, alpha)
 	C.BackgroundRGB(C.uint(r), C.uint(g), C.uint(b), C.VGfloat(alpha))
 }
 
@@ -249,72 +591,470 @@ func BackgroundRGB(r, g, b uint8, alpha VGfloat) {
 func BackgroundColor(s string, alpha ...VGfloat) {
 	c := Colorlookup(s)
 	if len(alpha) == 0 {
-		BackgroundRGB(c.R, c.G, c.B, 1)
+		BackgroundRGB(c.R, c.G, c.B, VGfloat(c.A)/255)
 	} else {
 		BackgroundRGB(c.R, c.G, c.B, alpha[0])
 	}
 }
 
-// makestops prepares the color/stop vector
+// lineargradients is whether gradients blend in linear light, as set by SetGradientSpace
+var lineargradients bool
+
+// SetGradientSpace sets how gradients set from now on blend between their colors. Colors, in
+// FillRGB, gradient stops, and everywhere else in this package, are sRGB values, as on the
+// screen, and by default, "srgb", OpenVG blends the values directly, so the midpoint of black and
+// white is the value 128. With "linear", gradients blend in linear light, as light mixes, so
+// the midpoint is the value 188, and blends of saturated colors keep their brightness; this is
+// done by adding intermediate stops. Unknown spaces leave the setting unchanged.
+func SetGradientSpace(space string) {
+	switch space {
+	case "srgb":
+		lineargradients = false
+	case "linear":
+		lineargradients = true
+	}
+}
+
+// maxrampstops is the number of gradient stops OpenVG is guaranteed to support
+const maxrampstops = 32
+
+// linearramp returns stops with stops added between each pair, so that blending them
+// as sRGB values approximates blending in linear light
+func linearramp(stops []Offcolor) []Offcolor {
+	steps := (maxrampstops - 1) / (len(stops) - 1)
+	if steps > 8 {
+		steps = 8
+	}
+	if steps < 2 {
+		return stops
+	}
+	ramp := []Offcolor{stops[0]}
+	for i := 1; i < len(stops); i++ {
+		a, b := stops[i-1], stops[i]
+		for k := 1; k <= steps; k++ {
+			t := float64(k) / float64(steps)
+			c := b
+			if k < steps {
+				c.Offset = a.Offset + (b.Offset-a.Offset)*VGfloat(t)
+				c.R = linearmix(a.R, b.R, t)
+				c.G = linearmix(a.G, b.G, t)
+				c.B = linearmix(a.B, b.B, t)
+				c.A = uint8(float64(a.A) + (float64(b.A)-float64(a.A))*t + 0.5)
+			}
+			ramp = append(ramp, c)
+		}
+	}
+	return ramp
+}
+
+// linearmix returns the sRGB value a fraction t of the way from a to b in linear light
+func linearmix(a, b uint8, t float64) uint8 {
+	la, lb := srgbtolinear(a), srgbtolinear(b)
+	return lineartosrgb(la + (lb-la)*t)
+}
+
+// srgbtolinear returns the linear light of an sRGB value, 0-1
+func srgbtolinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// lineartosrgb returns the sRGB value of linear light l, 0-1
+func lineartosrgb(l float64) uint8 {
+	var c float64
+	if l <= 0.0031308 {
+		c = l * 12.92
+	} else {
+		c = 1.055*math.Pow(l, 1/2.4) - 0.055
+	}
+	return uint8(c*255 + 0.5)
+}
+
+// makeramp prepares the color/stop vector: the stops sorted by offset, keeping the order of
+// equal offsets, which make a sharp change of color, with offsets clamped to 0-1, and
+// intermediate stops added for linear blending, if set by SetGradientSpace.
+// A single stop is repeated at both ends, so it fills with its color; with no stops, it returns nil.
 func makeramp(r []Offcolor) (*C.VGfloat, C.int) {
-	lr := len(r)
+	if len(r) == 0 {
+		return nil, 0
+	}
+	stops := append([]Offcolor(nil), r...)
+	for i := range stops {
+		stops[i].Offset = clamp01(stops[i].Offset)
+	}
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	if len(stops) == 1 {
+		stops = []Offcolor{{0, stops[0].RGBA}, {1, stops[0].RGBA}}
+	}
+	if lineargradients {
+		stops = linearramp(stops)
+	}
+	lr := len(stops)
 	nr := lr * 5
 	cs := make([]C.VGfloat, nr)
 	j := 0
 	for i := 0; i < lr; i++ {
-		cs[j] = C.VGfloat(r[i].Offset)
+		cs[j] = C.VGfloat(stops[i].Offset)
 		j++
-		cs[j] = C.VGfloat(VGfloat(r[i].R) / 255.0)
+		cs[j] = C.VGfloat(VGfloat(stops[i].R) / 255.0)
 		j++
-		cs[j] = C.VGfloat(VGfloat(r[i].G) / 255.0)
+		cs[j] = C.VGfloat(VGfloat(stops[i].G) / 255.0)
 		j++
-		cs[j] = C.VGfloat(VGfloat(r[i].B) / 255.0)
+		cs[j] = C.VGfloat(VGfloat(stops[i].B) / 255.0)
 		j++
-		cs[j] = C.VGfloat(VGfloat(r[i].A) / 255.0)
+		cs[j] = C.VGfloat(VGfloat(stops[i].A) / 255.0)
 		j++
 	}
 	return &cs[0], C.int(lr)
 }
 
 // FillLinearGradient sets up a linear gradient between (x1,y2) and (x2, y2)
-// using the specified offsets and colors in ramp
+// using the specified offsets and colors in ramp. The stops may be in any order, and offsets
+// are clamped to 0-1; a single stop fills with its color, and no stops leave the fill unchanged.
 func FillLinearGradient(x1, y1, x2, y2 VGfloat, ramp []Offcolor) {
+	checkinit()
 	cr, nr := makeramp(ramp)
+	if nr == 0 {
+		return
+	}
 	C.FillLinearGradient(C.VGfloat(x1), C.VGfloat(y1), C.VGfloat(x2), C.VGfloat(y2), cr, nr)
 }
 
 // FillRadialGradient sets up a radial gradient centered at (cx, cy), radius r,
-// with a focal point at (fx, fy) using the specified offsets and colors in ramp
+// with a focal point at (fx, fy) using the specified offsets and colors in ramp,
+// taken as for FillLinearGradient
 func FillRadialGradient(cx, cy, fx, fy, radius VGfloat, ramp []Offcolor) {
+	checkinit()
 	cr, nr := makeramp(ramp)
+	if nr == 0 {
+		return
+	}
 	C.FillRadialGradient(C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(fx), C.VGfloat(fy), C.VGfloat(radius), cr, nr)
 }
 
-// FillRGB sets the fill color, using RGB triples and alpha values
+// tilingmodes maps the names of pattern tiling modes to their OpenVG values
+var tilingmodes = map[string]C.VGTilingMode{
+	"fill":    C.VG_TILE_FILL,
+	"pad":     C.VG_TILE_PAD,
+	"repeat":  C.VG_TILE_REPEAT,
+	"reflect": C.VG_TILE_REFLECT,
+}
+
+// FillPattern sets the fill of subsequent shapes to the image, upright, with its lower left corner
+// at the origin, or its top left corner after SetOrigin("top-left"), in place of a color or gradient.
+// Beyond the image, tilingMode "repeat" (the default) tiles it, "reflect" tiles it mirrored,
+// "pad" extends its edge pixels, and "fill" leaves the shape transparent. Use GradientTransform to move or scale the pattern.
+func FillPattern(im image.Image, tilingMode string) {
+	checkinit()
+	bounds := im.Bounds()
+	if bounds.Empty() {
+		return
+	}
+	mode, ok := tilingmodes[tilingMode]
+	if !ok {
+		mode = C.VG_TILE_REPEAT
+	}
+	// the pattern's first row is at y 0, so with y increasing down it is the top row
+	data, w, h := orienteddata(im, false, topleft, 0)
+	C.FillPattern(C.int(w), C.int(h), &data[0], mode)
+}
+
+// colorramp spaces named colors evenly from offset 0 to 1; a single color fills the whole ramp
+func colorramp(colors []string) []Offcolor {
+	if len(colors) == 1 {
+		colors = append(colors, colors[0])
+	}
+	ramp := make([]Offcolor, len(colors))
+	for i, s := range colors {
+		ramp[i] = Offcolor{Offset: VGfloat(i) / VGfloat(len(colors)-1), RGBA: Colorlookup(s)}
+	}
+	return ramp
+}
+
+// FillLinearGradientColors sets up a linear gradient between (x1,y1) and (x2,y2)
+// through the named colors, as understood by Colorlookup, evenly spaced.
+// Without colors the fill is unchanged.
+func FillLinearGradientColors(x1, y1, x2, y2 VGfloat, colors ...string) {
+	if len(colors) == 0 {
+		return
+	}
+	FillLinearGradient(x1, y1, x2, y2, colorramp(colors))
+}
+
+// FillRadialGradientColors sets up a radial gradient centered at (cx,cy), with radius r,
+// through the named colors, evenly spaced from the center out.
+// Without colors the fill is unchanged.
+func FillRadialGradientColors(cx, cy, r VGfloat, colors ...string) {
+	if len(colors) == 0 {
+		return
+	}
+	FillRadialGradient(cx, cy, cx, cy, r, colorramp(colors))
+}
+
+// GradientTransform transforms the gradient or pattern fill by m, independently of the shapes it fills,
+// for example rotating a linear gradient or stretching a radial one into an ellipse.
+// m is column major, as the OpenVG matrix: {sx, shy, 0, shx, sy, 0, tx, ty, 1}.
+// The transform applies to gradients in user coordinates, until GradientTransformReset.
+func GradientTransform(m [9]VGfloat) {
+	checkinit()
+	var cm [9]C.VGfloat
+	for i, v := range m {
+		cm[i] = C.VGfloat(v)
+	}
+	C.GradientTransform(&cm[0])
+}
+
+// GradientTransformReset removes any transform set by GradientTransform
+func GradientTransformReset() {
+	checkinit()
+	C.GradientTransform(nil)
+}
+
+// DestroyPaint frees the paint objects reused by the fill color and gradient functions.
+// They are freed by Finish; calling it earlier is only needed to reclaim the memory
+// in a long-running program that no longer fills.
+func DestroyPaint() {
+	checkinit()
+	C.DestroyPaint()
+}
+
+// paintcount returns the number of paint objects made and not yet freed
+func paintcount() int {
+	checkinit()
+	return int(C.PaintCount())
+}
+
+// BackgroundGradient fills the window with a top to bottom linear gradient
+// using the specified offsets and colors in ramp, taken as for FillLinearGradient.
+// The fill is left set to the gradient. With no stops, nothing is drawn.
+func BackgroundGradient(ramp []Offcolor) {
+	checkinit()
+	cr, nr := makeramp(ramp)
+	if nr == 0 {
+		return
+	}
+	C.BackgroundLinearGradient(cr, nr)
+}
+
+// BackgroundRadialGradient fills the window with a radial gradient centered on the window,
+// using the specified offsets and colors in ramp, taken as for FillLinearGradient.
+// The fill is left set to the gradient. With no stops, nothing is drawn.
+func BackgroundRadialGradient(ramp []Offcolor) {
+	checkinit()
+	cr, nr := makeramp(ramp)
+	if nr == 0 {
+		return
+	}
+	C.BackgroundRadialGradient(cr, nr)
+}
+
+// FillRGB sets the fill color, using RGB triples and alpha values. The values are sRGB,
+// as on the screen, and passed to OpenVG unchanged.
 func FillRGB(r, g, b uint8, alpha VGfloat) {
+	checkinit()
 	C.Fill(C.uint(r), C.uint(g), C.uint(b), C.VGfloat(alpha))
 }
 
 // StrokeRGB sets the stroke color, using RGB triples
 func StrokeRGB(r, g, b uint8, alpha VGfloat) {
+	checkinit()
 	C.Stroke(C.uint(r), C.uint(g), C.uint(b), C.VGfloat(alpha))
 }
 
-// StrokeWidth sets the stroke width
+// strokewidth returns the width w as given to OpenVG, which rejects negative widths:
+// negative widths, and those not a number, are taken as 0, which draws no stroke
+func strokewidth(w VGfloat) C.VGfloat {
+	if !(w > 0) {
+		return 0
+	}
+	return C.VGfloat(w)
+}
+
+// StrokeWidth sets the stroke width; negative widths are taken as 0, which draws no stroke
 func StrokeWidth(w VGfloat) {
-	C.StrokeWidth(C.VGfloat(w))
+	checkinit()
+	C.StrokeWidth(strokewidth(w))
+}
+
+// CurrentStrokeWidth returns the stroke width, as set by StrokeWidth or restored by RestoreStyle
+func CurrentStrokeWidth() VGfloat {
+	checkinit()
+	return VGfloat(C.GetStrokeWidth())
+}
+
+// WithStrokeWidth sets the stroke width to w, calls draw, then restores the previous width,
+// so that one thing can be drawn thicker without changing the width for what follows.
+// Unlike StrokeWidth, it keeps the cap and join styles; the rest of the style is left as
+// draw leaves it, so use SaveStyle and RestoreStyle to restore everything.
+// As for StrokeWidth, negative widths are taken as 0.
+func WithStrokeWidth(w VGfloat, draw func()) {
+	checkinit()
+	prev := C.GetStrokeWidth()
+	C.SetStrokeWidth(strokewidth(w))
+	defer func() {
+		checkinit()
+		C.SetStrokeWidth(prev)
+	}()
+	draw()
+}
+
+// StrokeWidthPixels sets the stroke width so that lines are w pixels wide on the screen
+// under the current transformation, keeping hairlines and grid lines thin when zoomed with Scale.
+// The scale is read from the transformation matrix as the square root of its determinant,
+// the average of the x and y scales, so a non-uniform Scale gives lines of unequal width
+// across and along each axis. Call it after the transformation is set; under an
+// uninvertible transformation the width is set to w.
+func StrokeWidthPixels(w VGfloat) {
+	a, b, c, d, _, _ := usermatrix()
+	if s := VGfloat(math.Sqrt(math.Abs(float64(a*d - b*c)))); s > 0 {
+		w /= s
+	}
+	StrokeWidth(w)
+}
+
+// FillNone turns off filling of subsequent shapes and text, like SVG's fill="none",
+// until a fill color or gradient is set. Shapes drawn into a mask or clip path still count.
+func FillNone() {
+	checkinit()
+	C.FillNone()
+}
+
+// StrokeNone turns off stroking of subsequent shapes, like SVG's stroke="none",
+// until a stroke color is set
+func StrokeNone() {
+	checkinit()
+	C.StrokeNone()
+}
+
+// SaveStyle pushes the fill and stroke colors or gradients, stroke width, cap, join
+// and dash pattern onto a stack, so that drawing code can change them freely and
+// put back its caller's style with RestoreStyle. Calls may be nested.
+func SaveStyle() {
+	checkinit()
+	C.SaveStyle()
+}
+
+// RestoreStyle pops the style saved by the matching SaveStyle, making it current.
+// Without a saved style it does nothing.
+func RestoreStyle() {
+	checkinit()
+	C.RestoreStyle()
+}
+
+// FillRule sets how self-intersecting and holed shapes are filled:
+// "evenodd" leaves regions enclosed an even number of times empty,
+// "nonzero" fills any region with a non-zero winding count.
+// Unknown rules leave the setting unchanged.
+func FillRule(rule string) {
+	checkinit()
+	switch rule {
+	case "evenodd":
+		C.FillRule(C.VG_EVEN_ODD)
+	case "nonzero":
+		C.FillRule(C.VG_NON_ZERO)
+	}
+}
+
+// RenderingQuality sets the antialiasing of subsequent drawing: "none" turns it off,
+// for crisp pixel art or exactly reproducible output; "faster" trades quality for speed;
+// "better" is the default. Unknown qualities leave the setting unchanged.
+func RenderingQuality(q string) {
+	checkinit()
+	switch q {
+	case "none":
+		C.RenderingQuality(C.VG_RENDERING_QUALITY_NONANTIALIASED)
+	case "faster":
+		C.RenderingQuality(C.VG_RENDERING_QUALITY_FASTER)
+	case "better":
+		C.RenderingQuality(C.VG_RENDERING_QUALITY_BETTER)
+	}
+}
+
+// blendmodes maps blend mode names to OpenVG blend modes
+var blendmodes = map[string]C.VGBlendMode{
+	"src":      C.VG_BLEND_SRC,
+	"src-over": C.VG_BLEND_SRC_OVER,
+	"dst-over": C.VG_BLEND_DST_OVER,
+	"src-in":   C.VG_BLEND_SRC_IN,
+	"dst-in":   C.VG_BLEND_DST_IN,
+	"multiply": C.VG_BLEND_MULTIPLY,
+	"screen":   C.VG_BLEND_SCREEN,
+	"darken":   C.VG_BLEND_DARKEN,
+	"lighten":  C.VG_BLEND_LIGHTEN,
+	"additive": C.VG_BLEND_ADDITIVE,
+}
+
+// BlendMode sets how subsequent drawing is composited: "src", "src-over", "dst-over",
+// "src-in", "dst-in", "multiply", "screen", "darken", "lighten", or "additive".
+// Unknown modes leave the setting unchanged.
+func BlendMode(mode string) {
+	checkinit()
+	if m, ok := blendmodes[mode]; ok {
+		C.BlendMode(m)
+	}
+}
+
+// BlendModeReset restores the default "src-over" blending
+func BlendModeReset() {
+	checkinit()
+	C.BlendMode(C.VG_BLEND_SRC_OVER)
+}
+
+// ImageColorTransform scales then biases the red, green, blue and alpha of subsequently drawn images,
+// each channel becoming channel*scale + bias, for brightness, tinting or grayscale effects.
+// Images drawn by ImageFit and ImgAlpha are transformed, as are shapes and text;
+// Img and Image copy pixels directly and are not.
+func ImageColorTransform(scale [4]VGfloat, bias [4]VGfloat) {
+	checkinit()
+	var values [8]C.VGfloat
+	for i := 0; i < 4; i++ {
+		values[i] = C.VGfloat(scale[i])
+		values[i+4] = C.VGfloat(bias[i])
+	}
+	C.ColorTransform(&values[0])
+}
+
+// ImageColorTransformOff stops transforming colors
+func ImageColorTransformOff() {
+	checkinit()
+	C.ColorTransformOff()
+}
+
+// theme holds the named colors set by SetTheme
+var theme map[string]color.RGBA
+
+// SetTheme sets named colors, such as "background", "accent" and "text", for Colorlookup,
+// and so FillColor, StrokeColor and BackgroundColor, to look up before the SVG color names,
+// so that an application can be restyled by setting another theme. Names in the theme take
+// precedence over the SVG names, and its colors' alpha is used unless another is given.
+// The map is copied; a nil or empty map clears the theme.
+func SetTheme(colors map[string]color.RGBA) {
+	if len(colors) == 0 {
+		theme = nil
+		return
+	}
+	theme = make(map[string]color.RGBA, len(colors))
+	for name, c := range colors {
+		theme[name] = c
+	}
 }
 
-// Colorlookup returns a RGB triple corresponding to the named color,
-// or "rgb(r,g,b)" string. On error, return black.
+// Colorlookup returns a RGB triple corresponding to the named color, as set by SetTheme
+// or named by SVG, or "rgb(r,g,b)" string. On error, return black.
 func Colorlookup(s string) color.RGBA {
 	var rcolor = color.RGBA{0, 0, 0, 255}
+	if col, ok := theme[s]; ok {
+		return col
+	}
 	col, ok := colornames[s]
 	if ok {
 		return col
 	}
 	if strings.HasPrefix(s, "rgb(") {
-		n, err := fmt.Sscanf(s[3:], "(%d,%d,%d,%d)", &rcolor.R, &rcolor.G, &rcolor.B, &rcolor.A)
+		n, err := fmt.Sscanf(s[3:], "(%d,%d,%d)", &rcolor.R, &rcolor.G, &rcolor.B)
 		if n != 3 || err != nil {
 			return color.RGBA{0, 0, 0, 255}
 		}
@@ -327,55 +1067,290 @@ func Colorlookup(s string) color.RGBA {
 func FillColor(s string, alpha ...VGfloat) {
 	fc := Colorlookup(s)
 	if len(alpha) == 0 {
-		FillRGB(fc.R, fc.G, fc.B, 1)
+		FillRGB(fc.R, fc.G, fc.B, VGfloat(fc.A)/255)
 	} else {
 		FillRGB(fc.R, fc.G, fc.B, alpha[0])
 	}
 }
 
+// unwrapcolor splits a color into RGB triples and alpha.
+// As elsewhere in this package, color.RGBA values are taken as not premultiplied;
+// other colors are converted from Go's premultiplied form.
+func unwrapcolor(c color.Color) (r, g, b uint8, a VGfloat) {
+	if rgba, ok := c.(color.RGBA); ok {
+		return UnwrapRGBA(rgba)
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return n.R, n.G, n.B, VGfloat(n.A) / 255.0
+}
+
+// Fill sets the fill color from a color.Color
+func Fill(c color.Color) {
+	FillRGB(unwrapcolor(c))
+}
+
+// Stroke sets the stroke color from a color.Color
+func Stroke(c color.Color) {
+	StrokeRGB(unwrapcolor(c))
+}
+
+// FillHSL sets the fill color using hue (degrees), saturation and lightness (0..1), optionally applying alpha.
+func FillHSL(h, s, l VGfloat, alpha ...VGfloat) {
+	c := HSL(h, s, l)
+	if len(alpha) == 0 {
+		FillRGB(c.R, c.G, c.B, 1)
+	} else {
+		FillRGB(c.R, c.G, c.B, alpha[0])
+	}
+}
+
+// FillHSV sets the fill color using hue (degrees), saturation and value (0..1), optionally applying alpha.
+func FillHSV(h, s, v VGfloat, alpha ...VGfloat) {
+	c := HSV(h, s, v)
+	if len(alpha) == 0 {
+		FillRGB(c.R, c.G, c.B, 1)
+	} else {
+		FillRGB(c.R, c.G, c.B, alpha[0])
+	}
+}
+
 // StrokeColor sets the fill color using names to specify the color, optionally applying alpha.
 func StrokeColor(s string, alpha ...VGfloat) {
 	fc := Colorlookup(s)
 	if len(alpha) == 0 {
-		StrokeRGB(fc.R, fc.G, fc.B, 1)
+		StrokeRGB(fc.R, fc.G, fc.B, VGfloat(fc.A)/255)
 	} else {
 		StrokeRGB(fc.R, fc.G, fc.B, alpha[0])
 	}
 }
 
-// Start begins a picture
+// topleft is whether the origin is at the top left, as set by SetOrigin
+var topleft bool
+
+// SetOrigin sets where the origin of coordinates is from the next Start: "lower-left", the default,
+// with y increasing up, or "top-left", with y increasing down, as in most screen coordinate systems.
+// Everything follows the origin: shapes, and the window coordinates of ClipRect, Scissor
+// and Snapshot; AreaClear always has its origin at the top left. Text and images stay upright, so with "top-left" text rises above its baseline
+// toward smaller y, and an image's (x,y) is its top left corner. Rotations appear clockwise.
+// Unknown modes leave the origin unchanged.
+func SetOrigin(mode string) {
+	checkinit()
+	switch mode {
+	case "lower-left":
+		topleft = false
+	case "top-left":
+		topleft = true
+	default:
+		return
+	}
+	C.Origin(cbool(topleft))
+}
+
+// up returns y moved d units up the window, whichever way y increases
+func up(y, d VGfloat) VGfloat {
+	if topleft {
+		return y - d
+	}
+	return y + d
+}
+
+// textboxy returns the y for a box drawn by Rect from the depth below the text baseline at y
+// to the ascent above it
+func textboxy(y, ascent, depth VGfloat) VGfloat {
+	if topleft {
+		return y - ascent
+	}
+	return y - depth
+}
+
+// Start begins a picture, clearing the area (0,0) to (w,h) to white, or to the optional color:
+// red, green and blue, with an optional fourth alpha value (0-255). Any other number of
+// color values is ignored, clearing to white. The fill and stroke are set to black.
 func Start(w, h int, color ...uint8) {
-	C.Start(C.int(w), C.int(h))
-	if len(color) == 3 {
-		Background(color[0], color[1], color[2])
+	checkinit()
+	switch len(color) {
+	case 3:
+		C.StartRGB(C.int(w), C.int(h), C.uint(color[0]), C.uint(color[1]), C.uint(color[2]), 1)
+		svgbackground(color[0], color[1], color[2], 1)
+	case 4:
+		C.StartRGB(C.int(w), C.int(h), C.uint(color[0]), C.uint(color[1]), C.uint(color[2]), C.VGfloat(color[3])/255)
+		svgbackground(color[0], color[1], color[2], VGfloat(color[3])/255)
+	default:
+		C.Start(C.int(w), C.int(h))
+		svgbackground(255, 255, 255, 1)
 	}
 }
 
-// StartColor begins the picture with the specified color background
+// StartColor begins the picture with the specified color background, with the color's
+// alpha, as set by SetTheme, unless alpha is given
 func StartColor(w, h int, color string, alpha ...VGfloat) {
-	C.Start(C.int(w), C.int(h))
-	BackgroundColor(color, alpha...)
+	checkinit()
+	c := Colorlookup(color)
+	a := VGfloat(c.A) / 255
+	if len(alpha) > 0 {
+		a = alpha[0]
+	}
+	C.StartRGB(C.int(w), C.int(h), C.uint(c.R), C.uint(c.G), C.uint(c.B), C.VGfloat(a))
+	svgbackground(c.R, c.G, c.B, a)
 }
 
-// End ends the picture
+// End ends the picture, presenting it by swapping the display buffers.
+// Drawing is not shown until End, so a whole frame can be built and then presented at once.
 func End() {
+	checkinit()
+	drawoverlay()
 	C.End()
 }
 
+// overlay draws on top of every frame, if set
+var overlay func()
+
+// SetOverlay arranges for draw to be called at each End and SaveEnd, just before the
+// frame is presented, to draw on top of it, for example a frame rate or a watermark.
+// It draws with the transformation set by Start, the style as left by the frame,
+// and any clipping still in effect; the frame's transformation and style are restored after.
+func SetOverlay(draw func()) {
+	overlay = draw
+}
+
+// ClearOverlay stops drawing the overlay set by SetOverlay
+func ClearOverlay() {
+	overlay = nil
+}
+
+// drawoverlay draws the overlay, if any, untransformed, keeping the frame's transformation and style
+func drawoverlay() {
+	if overlay == nil {
+		return
+	}
+	var saved [9]C.VGfloat
+	C.GetMatrix(&saved[0])
+	C.SaveStyle()
+	defer func() {
+		flushbatch()
+		C.RestoreStyle()
+		C.LoadMatrix(&saved[0])
+	}()
+	m := startmatrix(0, 0)
+	C.LoadMatrix(&m[0])
+	overlay()
+}
+
+// startmatrix returns the transformation set by Start, translated by (x,y) in window coordinates
+func startmatrix(x, y int) [9]C.VGfloat {
+	m := [9]C.VGfloat{1, 0, 0, 0, 1, 0, C.VGfloat(x), C.VGfloat(y), 1}
+	if topleft {
+		m[4], m[7] = -1, C.VGfloat(winheight-y)
+	}
+	return m
+}
+
+// SwapInterval sets the number of video frames End waits for before presenting;
+// 1 synchronizes with vsync, 0 presents immediately
+func SwapInterval(n int) {
+	checkinit()
+	C.SwapInterval(C.int(n))
+}
+
 // SaveEnd ends the picture, saving the raw raster
 func SaveEnd(filename string) {
+	checkinit()
 	s := C.CString(filename)
 	defer C.free(unsafe.Pointer(s))
+	drawoverlay()
 	C.SaveEnd(s)
 }
 
-// fakeimage makes a placeholder for a missing image
-func fakeimage(x, y VGfloat, w, h int, s string) {
-	fw := VGfloat(w)
-	fh := VGfloat(h)
-	FillColor("lightgray")
-	Rect(x, y, fw, fh)
-	StrokeWidth(1)
+// readpixels returns the red, green, blue, alpha bytes of a region in window coordinates,
+// top row first
+func readpixels(x, y, w, h int) []byte {
+	checkinit()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	data := make([]byte, w*h*4)
+	C.ReadPixels(C.int(x), C.int(y), C.int(w), C.int(h), (*C.VGubyte)(unsafe.Pointer(&data[0])))
+	stride := w * 4
+	row := make([]byte, stride)
+	for top, bottom := 0, h-1; top < bottom; top, bottom = top+1, bottom-1 { // OpenVG has origin at lower left
+		t := data[top*stride : (top+1)*stride]
+		b := data[bottom*stride : (bottom+1)*stride]
+		copy(row, t)
+		copy(t, b)
+		copy(b, row)
+	}
+	return data
+}
+
+// RegionChanged reads the pixels of the rectangle at (x,y) with dimensions (w,h), in window
+// coordinates, as drawn so far, and reports whether they differ from prev, the pixels returned by
+// the previous call for the region, which are returned in their place for the next call.
+// A program that redraws every frame can then skip End when nothing visible has changed.
+// A nil or differently sized prev counts as changed; an empty region never changes.
+func RegionChanged(x, y, w, h int, prev []byte) (bool, []byte) {
+	checkinit()
+	if w <= 0 || h <= 0 {
+		return false, nil
+	}
+	data := make([]byte, w*h*4) // bottom row first, as read; only compared
+	C.ReadPixels(C.int(x), C.int(y), C.int(w), C.int(h), (*C.VGubyte)(unsafe.Pointer(&data[0])))
+	return !bytes.Equal(data, prev), data
+}
+
+// PixelAt returns the color of the pixel at (x,y) in window coordinates, which have their
+// origin at the lower left, or the top left after SetOrigin("top-left"), for an eyedropper
+// or debugging. Like other colors in this package, it is not premultiplied by alpha.
+// Pixels outside the window are opaque black.
+func PixelAt(x, y int) color.RGBA {
+	checkinit()
+	if x < 0 || y < 0 || x >= winwidth || y >= winheight {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	p := readpixels(x, y, 1, 1)
+	return color.RGBA{p[0], p[1], p[2], p[3]}
+}
+
+// SetColorSpace sets whether pixels read back, as by Snapshot and SaveJPEG, are linear values,
+// or sRGB values (the default). sRGB matches what is shown on the display and what
+// image files expect; linear values are proportional to light intensity, for computation.
+func SetColorSpace(linear bool) {
+	checkinit()
+	C.ReadColorSpace(cbool(linear))
+}
+
+// Snapshot returns the picture drawn so far in the area (0,0) to (w,h),
+// for example to save or compare the output of InitOffscreen.
+// Pixels are in the sRGB color space, unless changed by SetColorSpace.
+func Snapshot(w, h int) *image.NRGBA {
+	return &image.NRGBA{Pix: readpixels(0, 0, w, h), Stride: w * 4, Rect: image.Rect(0, 0, w, h)}
+}
+
+// SaveJPEG saves the picture drawn so far in the whole window as a JPEG file,
+// at the specified quality (1-100). The window keeps its picture after End,
+// so it may be called after End as well as before.
+func SaveJPEG(filename string, quality int) error {
+	if quality < 1 || quality > 100 {
+		return fmt.Errorf("openvg: JPEG quality %d is not between 1 and 100", quality)
+	}
+	im := Snapshot(winwidth, winheight)
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, im, &jpeg.Options{Quality: quality}); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fakeimage makes a placeholder for a missing image
+func fakeimage(x, y VGfloat, w, h int, s string) {
+	fw := VGfloat(w)
+	fh := VGfloat(h)
+	FillColor("lightgray")
+	Rect(x, y, fw, fh)
+	StrokeWidth(1)
 	StrokeColor("gray")
 	Line(x, y, x+fw, y+fh)
 	Line(x, y+fh, x+fw, y)
@@ -384,100 +1359,732 @@ func fakeimage(x, y VGfloat, w, h int, s string) {
 	TextMid(x+(fw/2), y+(fh/2), s, "sans", w/20)
 }
 
+// imagedata copies the region r of an image into red, green, blue, alpha bytes, not premultiplied,
+// bottom row first, as OpenVG has its origin at lower left, y increasing up
+func imagedata(im image.Image, r image.Rectangle) []C.VGubyte {
+	data := make([]C.VGubyte, r.Dx()*r.Dy()*4)
+	n := 0
+	for yp := r.Max.Y - 1; yp >= r.Min.Y; yp-- {
+		for xp := r.Min.X; xp < r.Max.X; xp++ {
+			putpixel(data[n:], im.At(xp, yp))
+			n += 4
+		}
+	}
+	return data
+}
+
+// putpixel stores the red, green, blue, alpha bytes of a color, not premultiplied, at the start of data
+func putpixel(data []C.VGubyte, c color.Color) {
+	red, g, b, a := c.RGBA()
+	if a > 0 && a < 0xffff { // RGBA premultiplies by alpha; the images are made from straight colors
+		red, g, b = red*0xffff/a, g*0xffff/a, b*0xffff/a
+	}
+	data[0] = C.VGubyte(red >> 8)
+	data[1] = C.VGubyte(g >> 8)
+	data[2] = C.VGubyte(b >> 8)
+	data[3] = C.VGubyte(a >> 8)
+}
+
+// orienteddata copies an image into red, green, blue, alpha bytes, bottom row first, like imagedata,
+// mirrored left to right if flipH, top to bottom if flipV, then turned clockwise by rotate90
+// quarter turns, returning the bytes and the dimensions of the oriented image
+func orienteddata(im image.Image, flipH, flipV bool, rotate90 int) ([]C.VGubyte, int, int) {
+	r := im.Bounds()
+	w, h := r.Dx(), r.Dy()
+	rotate90 = ((rotate90 % 4) + 4) % 4
+	ow, oh := w, h
+	if rotate90%2 == 1 {
+		ow, oh = h, w
+	}
+	data := make([]C.VGubyte, w*h*4)
+	n := 0
+	for oy := oh - 1; oy >= 0; oy-- {
+		for ox := 0; ox < ow; ox++ {
+			// the pixel of the flipped image, counting from its top left, turned to (ox,oy)
+			x, y := ox, oy
+			switch rotate90 {
+			case 1:
+				x, y = oy, h-1-ox
+			case 2:
+				x, y = w-1-ox, h-1-oy
+			case 3:
+				x, y = w-1-oy, ox
+			}
+			if flipH {
+				x = w - 1 - x
+			}
+			if flipV {
+				y = h - 1 - y
+			}
+			putpixel(data[n:], im.At(r.Min.X+x, r.Min.Y+y))
+			n += 4
+		}
+	}
+	return data, ow, oh
+}
+
 // Img places an image object at (x,y)
 func Img(x, y VGfloat, im image.Image) {
+	checkinit()
 	bounds := im.Bounds()
-	minx := bounds.Min.X
-	maxx := bounds.Max.X
-	miny := bounds.Min.Y
-	maxy := bounds.Max.Y
-	data := make([]C.VGubyte, bounds.Dx()*bounds.Dy()*4)
-	n := 0
-	var r, g, b, a uint32
-	for yp := miny; yp < maxy; yp++ {
-		for xp := minx; xp < maxx; xp++ {
-			r, g, b, a = im.At(xp, (maxy-1)-yp).RGBA() // OpenVG has origin at lower left, y increasing up
-			data[n] = C.VGubyte(r >> 8)
-			n++
-			data[n] = C.VGubyte(g >> 8)
-			n++
-			data[n] = C.VGubyte(b >> 8)
-			n++
-			data[n] = C.VGubyte(a >> 8)
-			n++
-		}
+	if bounds.Empty() {
+		return
 	}
+	data := imagedata(im, bounds)
 	C.makeimage(C.VGfloat(x), C.VGfloat(y), C.int(bounds.Dx()), C.int(bounds.Dy()), &data[0])
 }
 
+// ImgOriented places an image at (x,y), like Img, reoriented as it is copied: mirrored left to right
+// if flipH, top to bottom if flipV, and then turned clockwise by rotate90 quarter turns (negative
+// values turn counterclockwise), as needed for photos with an EXIF orientation or mirrored sprites.
+// The lower left corner of the reoriented image is at (x,y); its width and height are exchanged
+// by an odd number of quarter turns.
+func ImgOriented(x, y VGfloat, im image.Image, flipH, flipV bool, rotate90 int) {
+	checkinit()
+	if im.Bounds().Empty() {
+		return
+	}
+	data, w, h := orienteddata(im, flipH, flipV, rotate90)
+	C.makeimage(C.VGfloat(x), C.VGfloat(y), C.int(w), C.int(h), &data[0])
+}
+
+// Premultiply multiplies the red, green and blue of each pixel of pix, red, green, blue,
+// alpha bytes, by its alpha, in place, rounding to the nearest value. Pixels drawn by ImgRaw
+// and read by ReadPixels are not premultiplied; Go's image.RGBA, for one, is.
+func Premultiply(pix []byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		for k := i; k < i+3; k++ {
+			pix[k] = uint8((uint32(pix[k])*a + 127) / 255)
+		}
+	}
+}
+
+// Unpremultiply divides the red, green and blue of each pixel of pix, red, green, blue,
+// alpha bytes, by its alpha, in place, rounding to the nearest value, so that premultiplied
+// data, such as the Pix of an image.RGBA, can be passed to ImgRaw. Transparent pixels are
+// made transparent black, and colors beyond their alpha, invalid when premultiplied, white.
+func Unpremultiply(pix []byte) {
+	for i := 0; i+3 < len(pix); i += 4 {
+		a := uint32(pix[i+3])
+		for k := i; k < i+3; k++ {
+			switch c := uint32(pix[k]); {
+			case a == 0:
+				pix[k] = 0
+			case c >= a:
+				pix[k] = 255
+			default:
+				pix[k] = uint8((c*255 + a/2) / a)
+			}
+		}
+	}
+}
+
+// rawbuf holds the rows of ImgRaw images, bottom row first, reused from frame to frame
+var rawbuf []byte
+
+// ImgRaw places an image of dimensions (w,h) at (x,y), like Img, from pix holding red, green,
+// blue, alpha bytes, not premultiplied, top row first, with stride bytes from the start of one
+// row to the next, as from a camera or video decoder. It avoids converting to an image.Image,
+// and reuses its buffer, so streaming frames allocates nothing. An error is returned if pix is
+// too short for the dimensions and stride.
+func ImgRaw(x, y VGfloat, w, h int, pix []byte, stride int) error {
+	checkinit()
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	if stride < w*4 || len(pix) < stride*h {
+		return fmt.Errorf("openvg: %d bytes with stride %d is too few for a %dx%d image", len(pix), stride, w, h)
+	}
+	row := w * 4
+	if cap(rawbuf) < row*h {
+		rawbuf = make([]byte, row*h)
+	}
+	data := rawbuf[:row*h]
+	for i := 0; i < h; i++ { // bottom row first
+		copy(data[i*row:(i+1)*row], pix[(h-1-i)*stride:])
+	}
+	C.makeimage(C.VGfloat(x), C.VGfloat(y), C.int(w), C.int(h), (*C.VGubyte)(unsafe.Pointer(&data[0])))
+	return nil
+}
+
+// ImgGray places a grayscale image at (x,y), like Img, uploading one byte per pixel
+// rather than four, which saves memory bandwidth for sensor data and masks
+func ImgGray(x, y VGfloat, im *image.Gray) {
+	checkinit()
+	b := im.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if b.Empty() {
+		return
+	}
+	data := make([]C.VGubyte, w*h)
+	for row := 0; row < h; row++ { // bottom row first
+		src := im.Pix[im.PixOffset(b.Min.X, b.Max.Y-1-row):]
+		for col := 0; col < w; col++ {
+			data[row*w+col] = C.VGubyte(src[col])
+		}
+	}
+	C.makeimageformat(C.VGfloat(x), C.VGfloat(y), C.int(w), C.int(h), &data[0], C.VG_sL_8, C.int(w))
+}
+
+// drawimage draws the region r of an image scaled to (w,h) at (x,y)
+func drawimage(x, y, w, h VGfloat, im image.Image, r image.Rectangle) {
+	checkinit()
+	if r.Empty() {
+		return
+	}
+	data := imagedata(im, r)
+	C.DrawImage(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.int(r.Dx()), C.int(r.Dy()), &data[0])
+}
+
+// ImgAlpha places an image object at (x,y), blended with the drawing beneath
+// at the specified opacity (0.0-1.0, clamped), as for fading an image in or out
+func ImgAlpha(x, y VGfloat, im image.Image, opacity VGfloat) {
+	checkinit()
+	bounds := im.Bounds()
+	opacity = clamp01(opacity)
+	if bounds.Empty() || opacity == 0 {
+		return
+	}
+	data := imagedata(im, bounds)
+	for i := 3; i < len(data); i += 4 {
+		data[i] = C.VGubyte(VGfloat(data[i])*opacity + 0.5)
+	}
+	w, h := bounds.Dx(), bounds.Dy()
+	C.DrawImage(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.int(w), C.int(h), &data[0])
+}
+
+// ImgRegion draws the region of src with top left corner (sx,sy), in the image's coordinates,
+// and dimensions (sw,sh), placing its lower left corner at (x,y); only the region's pixels are copied.
+// The region is clamped to the image, with the clamped part left undrawn.
+func ImgRegion(x, y VGfloat, src image.Image, sx, sy, sw, sh int) {
+	want := image.Rect(sx, sy, sx+sw, sy+sh)
+	r := want.Intersect(src.Bounds())
+	if r.Empty() {
+		return
+	}
+	x += VGfloat(r.Min.X - want.Min.X)
+	if topleft {
+		y += VGfloat(r.Min.Y - want.Min.Y) // rows cut from the top lower the region
+	} else {
+		y += VGfloat(want.Max.Y - r.Max.Y) // y increases up, so rows cut from the bottom raise the region
+	}
+	drawimage(x, y, VGfloat(r.Dx()), VGfloat(r.Dy()), src, r)
+}
+
+// ImgTiled covers the region at (x,y) with dimensions (w,h) with copies of an image,
+// the first with its lower left corner at (x,y). Tiles at the top and right edges
+// are cut to the region, rather than the image being scaled.
+func ImgTiled(x, y VGfloat, w, h int, im image.Image) {
+	checkinit()
+	b := im.Bounds()
+	iw, ih := b.Dx(), b.Dy()
+	if b.Empty() || w <= 0 || h <= 0 {
+		return
+	}
+	full := imagedata(im, b) // converted once for all the whole tiles
+	for ty := 0; ty < h; ty += ih {
+		th := ih
+		if h-ty < th {
+			th = h - ty
+		}
+		for tx := 0; tx < w; tx += iw {
+			tw := iw
+			if w-tx < tw {
+				tw = w - tx
+			}
+			px, py := x+VGfloat(tx), y+VGfloat(ty)
+			if tw == iw && th == ih {
+				C.DrawImage(C.VGfloat(px), C.VGfloat(py), C.VGfloat(iw), C.VGfloat(ih), C.int(iw), C.int(ih), &full[0])
+				continue
+			}
+			// with y increasing up, a tile cut at the top keeps the bottom of the image
+			cut := image.Rect(b.Min.X, b.Max.Y-th, b.Min.X+tw, b.Max.Y)
+			if topleft {
+				cut = image.Rect(b.Min.X, b.Min.Y, b.Min.X+tw, b.Min.Y+th)
+			}
+			drawimage(px, py, VGfloat(tw), VGfloat(th), im, cut)
+		}
+	}
+}
+
+// ImgRotated draws an image centered at (cx,cy), rotated counter-clockwise by angle degrees.
+// The transformation is restored afterward.
+func ImgRotated(cx, cy VGfloat, angle VGfloat, im image.Image) {
+	bounds := im.Bounds()
+	w, h := VGfloat(bounds.Dx()), VGfloat(bounds.Dy())
+	WithTransform(cx, cy, angle, 1, 1, func() {
+		drawimage(-w/2, -h/2, w, h, im, bounds)
+	})
+}
+
+// ImageResampling sets how scaled or rotated images, as drawn by ImageFit and ImgRotated,
+// are resampled: "none" uses the nearest pixel, for crisp pixel art; "bilinear",
+// the default, smooths photos; "better" may use a higher quality filter.
+// Unknown qualities leave the setting unchanged.
+func ImageResampling(q string) {
+	checkinit()
+	switch q {
+	case "none":
+		C.ImageQuality(C.VG_IMAGE_QUALITY_NONANTIALIASED)
+	case "bilinear":
+		C.ImageQuality(C.VG_IMAGE_QUALITY_FASTER)
+	case "better":
+		C.ImageQuality(C.VG_IMAGE_QUALITY_BETTER)
+	}
+}
+
+// ImageFit draws an image within the box at (x,y) with dimensions (w,h), keeping its aspect ratio.
+// With mode "contain" the whole image is scaled to fit, centered, leaving empty bands as needed;
+// with "cover" the image is scaled to fill the box, and centrally cropped to it.
+// Any other mode is treated as "contain".
+func ImageFit(x, y VGfloat, w, h int, mode string, im image.Image) {
+	bounds := im.Bounds()
+	if bounds.Empty() || w <= 0 || h <= 0 {
+		return
+	}
+	iw, ih := float64(bounds.Dx()), float64(bounds.Dy())
+	sx, sy := float64(w)/iw, float64(h)/ih
+	if mode == "cover" {
+		s := math.Max(sx, sy)
+		cw, ch := int(math.Round(float64(w)/s)), int(math.Round(float64(h)/s))
+		crop := image.Rect(0, 0, cw, ch).Add(bounds.Min).Add(image.Pt((bounds.Dx()-cw)/2, (bounds.Dy()-ch)/2))
+		drawimage(x, y, VGfloat(w), VGfloat(h), im, crop.Intersect(bounds))
+		return
+	}
+	s := math.Min(sx, sy)
+	dw, dh := VGfloat(iw*s), VGfloat(ih*s)
+	drawimage(x+(VGfloat(w)-dw)/2, y+(VGfloat(h)-dh)/2, dw, dh, im, bounds)
+}
+
+// imagefallback draws in place of an image which cannot be read
+var imagefallback = fakeimage
+
+// strictimages is whether images which cannot be read are left undrawn
+var strictimages bool
+
+// SetImageFallback sets the function called by Image, in place of an image file which cannot
+// be opened or decoded, with the position, dimensions and name given to Image, so that an
+// application can draw its own placeholder, or nothing. SetImageFallback(nil) restores the
+// default, a gray box crossed out and labeled with the name.
+func SetImageFallback(f func(x, y VGfloat, w, h int, name string)) {
+	if f == nil {
+		f = fakeimage
+	}
+	imagefallback = f
+}
+
+// StrictImages sets whether an image file which cannot be opened or decoded is left undrawn
+// by Image and ImageErr, rather than replaced by the fallback set by SetImageFallback
+func StrictImages(strict bool) {
+	strictimages = strict
+}
+
 // Image places the named image at (x,y) with dimensions (w,h)
 // the specified derived image dimensions override the native ones.
 func Image(x, y VGfloat, w, h int, s string) {
+	ImageErr(x, y, w, h, s)
+}
 
-	var img image.Image
-	var derr error
+// ImageErr is Image, returning an error if the image file cannot be opened or decoded.
+// The fallback is drawn in its place, unless images are strict (see StrictImages).
+func ImageErr(x, y VGfloat, w, h int, s string) error {
 	f, err := os.Open(s)
 	if err != nil {
-		fakeimage(x, y, w, h, s)
-		return
+		imagemissing(x, y, w, h, s)
+		return err
 	}
-	img, _, derr = image.Decode(f)
 	defer f.Close()
-	if derr != nil {
-		fakeimage(x, y, w, h, s)
-		return
+	img, _, err := image.Decode(f)
+	if err != nil {
+		imagemissing(x, y, w, h, s)
+		return fmt.Errorf("openvg: %s: %v", s, err)
 	}
 	Img(x, y, img)
+	return nil
+}
+
+// imagemissing draws the fallback for an image which cannot be read, unless images are strict
+func imagemissing(x, y VGfloat, w, h int, s string) {
+	if !strictimages {
+		imagefallback(x, y, w, h, s)
+	}
+}
+
+// LoadImageAsync opens the named image file, and decodes it on another goroutine, so that
+// a large image does not stall drawing. The decoded image is sent on the returned channel,
+// which is then closed; if the file cannot be decoded, nil is sent. The image should be drawn,
+// with Img or ImageFit, from the drawing goroutine, as usual. An error is returned if the
+// file cannot be opened.
+func LoadImageAsync(path string) (<-chan image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	c := make(chan image.Image, 1)
+	go func() {
+		defer close(c)
+		defer f.Close()
+		img, _, err := image.Decode(f)
+		if err != nil {
+			img = nil
+		}
+		c <- img
+	}()
+	return c, nil
 }
 
 // Line draws a line between two points
 func Line(x1, y1, x2, y2 VGfloat) {
+	checkthread()
+	svgline(x1, y1, x2, y2)
+	if batchline(x1, y1, x2, y2) {
+		return
+	}
+	checkinit()
 	C.Line(C.VGfloat(x1), C.VGfloat(y1), C.VGfloat(x2), C.VGfloat(y2))
 }
 
+// Lines draws each segment, given as x1, y1, x2, y2, as a line,
+// in a single call, which is much faster than calling Line for each
+func Lines(segments [][4]VGfloat) {
+	checkinit()
+	if len(segments) == 0 {
+		return
+	}
+	coords := make([]C.VGfloat, 0, len(segments)*4)
+	for _, s := range segments {
+		svgline(s[0], s[1], s[2], s[3])
+		coords = append(coords, C.VGfloat(s[0]), C.VGfloat(s[1]), C.VGfloat(s[2]), C.VGfloat(s[3]))
+	}
+	C.Lines(&coords[0], C.int(len(segments)))
+}
+
+// snappixel returns the window coordinate v moved to the nearest pixel center, where a line
+// an odd number of pixels wide is crisp, if odd, or otherwise to the nearest pixel edge
+func snappixel(v VGfloat, odd bool) VGfloat {
+	if odd {
+		return VGfloat(math.Floor(float64(v))) + 0.5
+	}
+	return VGfloat(math.Floor(float64(v) + 0.5))
+}
+
+// gridline draws a horizontal line from (x1,y) to (x2,y), or if vertical, a vertical line
+// from (y,x1) to (y,x2), snapped to the pixel grid
+func gridline(x1, x2, y VGfloat, vertical bool) {
+	a, b, c, d, e, f := usermatrix()
+	if vertical { // exchange the axes
+		a, b, c, d, e, f = d, c, b, a, f, e
+	}
+	if b != 0 || c != 0 || a == 0 || d == 0 { // rotated or sheared: no grid to snap to
+		if vertical {
+			Line(y, x1, y, x2)
+		} else {
+			Line(x1, y, x2, y)
+		}
+		return
+	}
+	n := math.Floor(math.Abs(float64(d*CurrentStrokeWidth())) + 0.5)
+	y = (snappixel(d*y+f, n <= 1 || math.Mod(n, 2) == 1) - f) / d
+	x1 = (snappixel(a*x1+e, false) - e) / a
+	x2 = (snappixel(a*x2+e, false) - e) / a
+	if vertical {
+		Line(y, x1, y, x2)
+	} else {
+		Line(x1, y, x2, y)
+	}
+}
+
+// HLine draws a horizontal line from (x1,y) to (x2,y), moved by up to half a pixel so that it
+// lies on the pixel grid and is drawn crisply, without the blur of antialiasing across two rows
+// of pixels, as wanted for grid lines and table borders. The ends are snapped to pixel edges.
+// It accounts for the stroke width and the current Translate and Scale; under a rotation or
+// shear it draws like Line.
+func HLine(x1, x2, y VGfloat) {
+	gridline(x1, x2, y, false)
+}
+
+// VLine draws a vertical line from (x,y1) to (x,y2), snapped to the pixel grid like HLine
+func VLine(y1, y2, x VGfloat) {
+	gridline(y1, y2, x, true)
+}
+
+// Points draws a dot, a filled circle of the specified radius, centered at each point,
+// in a single call, which is much faster than calling Circle for each.
+// Coordinate slices of differing lengths draw nothing.
+func Points(x, y []VGfloat, radius VGfloat) {
+	checkinit()
+	px, py, np := poly(x, y)
+	if np > 0 {
+		C.Points(px, py, C.int(np), C.VGfloat(radius))
+	}
+}
+
+// Dots draws a single pixel (a one unit square, when transformed) at each point,
+// in a single call. Coordinate slices of differing lengths draw nothing.
+func Dots(x, y []VGfloat) {
+	checkinit()
+	px, py, np := poly(x, y)
+	if np > 0 {
+		C.Dots(px, py, C.int(np))
+	}
+}
+
+// emptyshape reports whether a shape of dimensions (w,h) is empty: zero, negative or not
+// a number. Rather than passing them to OpenVG, which would record an error, the shapes
+// with such dimensions or radii draw nothing, once checkinit has been called; shapes that
+// may be batched, and Line, call checkthread instead, as checkinit would draw the batch.
+func emptyshape(w, h VGfloat) bool {
+	return !(w > 0 && h > 0)
+}
+
 // Rect draws a rectangle at (x,y) with dimesions (w,h)
 func Rect(x, y, w, h VGfloat) {
+	checkthread()
+	if emptyshape(w, h) {
+		return
+	}
+	svgrect(x, y, w, h, 0, 0, true)
+	if batchrect(true, x, y, w, h) {
+		return
+	}
+	checkinit()
 	C.Rect(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h))
 }
 
+// RectGradient draws a rectangle at (x,y) with dimensions (w,h), filled with a linear gradient
+// from its top (offset 0) to its bottom (offset 1), as for BackgroundLinearGradient.
+// The fill is left set to the gradient.
+func RectGradient(x, y, w, h VGfloat, ramp []Offcolor) {
+	top, bottom := y+h, y
+	if topleft {
+		top, bottom = y, y+h
+	}
+	FillLinearGradient(x, top, x, bottom, ramp)
+	Rect(x, y, w, h)
+}
+
+// RectGradientHorizontal draws a rectangle at (x,y) with dimensions (w,h), filled with
+// a linear gradient from its left (offset 0) to its right (offset 1).
+// The fill is left set to the gradient.
+func RectGradientHorizontal(x, y, w, h VGfloat, ramp []Offcolor) {
+	FillLinearGradient(x, y, x+w, y, ramp)
+	Rect(x, y, w, h)
+}
+
+// RectOutline strokes a rectangle at (x,y) with dimensions (w,h), without filling it
+func RectOutline(x, y, w, h VGfloat) {
+	checkthread()
+	if emptyshape(w, h) {
+		return
+	}
+	svgrect(x, y, w, h, 0, 0, false)
+	if batchrect(false, x, y, w, h) {
+		return
+	}
+	checkinit()
+	C.RectOutline(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h))
+}
+
+// RectDashed strokes a rectangle at (x,y) with dimensions (w,h), without filling it, with dashes:
+// pattern alternates the lengths of dashes and gaps, and phase, if given, is the distance into the
+// pattern at which the outline begins. Increasing the phase each frame makes the dashes march
+// around the rectangle, as in a selection marquee. The dashing is in effect only for this outline.
+func RectDashed(x, y, w, h VGfloat, pattern []VGfloat, phase ...VGfloat) {
+	checkinit()
+	var p VGfloat
+	if len(phase) > 0 {
+		p = phase[0]
+	}
+	dash := make([]C.VGfloat, len(pattern)+1) // never empty, to take its address
+	for i, d := range pattern {
+		dash[i] = C.VGfloat(d)
+	}
+	C.RectDashed(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), &dash[0], C.int(len(pattern)), C.VGfloat(p))
+}
+
 // Roundrect draws a rounded rectangle at (x,y) with dimesions (w,h).
-// the corner radii are at (rw, rh)
+// the corner radii are at (rw, rh); negative radii are taken as zero
 func Roundrect(x, y, w, h, rw, rh VGfloat) {
+	checkinit()
+	if emptyshape(w, h) {
+		return
+	}
+	rw, rh = maxfloat(rw, 0), maxfloat(rh, 0)
+	svgrect(x, y, w, h, rw, rh, true)
 	C.Roundrect(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.VGfloat(rw), C.VGfloat(rh))
 }
 
+// RoundrectCorners draws a rectangle at (x,y) with dimensions (w,h), rounding its top left,
+// top right, bottom right and bottom left corners with the radii tl, tr, br and bl;
+// a zero radius makes a square corner, so that, for example, only the top corners are rounded.
+// Radii too large for the sides are reduced in proportion, and negative radii are taken as zero.
+func RoundrectCorners(x, y, w, h, tl, tr, br, bl VGfloat) {
+	checkinit()
+	if emptyshape(w, h) {
+		return
+	}
+	tl, tr, br, bl = maxfloat(tl, 0), maxfloat(tr, 0), maxfloat(br, 0), maxfloat(bl, 0)
+	C.RoundrectCorners(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.VGfloat(tl), C.VGfloat(tr), C.VGfloat(br), C.VGfloat(bl))
+}
+
+// RoundrectOutline strokes a rounded rectangle at (x,y) with dimensions (w,h),
+// and corner radii (rw, rh), without filling it; negative radii are taken as zero
+func RoundrectOutline(x, y, w, h, rw, rh VGfloat) {
+	checkinit()
+	if emptyshape(w, h) {
+		return
+	}
+	rw, rh = maxfloat(rw, 0), maxfloat(rh, 0)
+	svgrect(x, y, w, h, rw, rh, false)
+	C.RoundrectOutline(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.VGfloat(rw), C.VGfloat(rh))
+}
+
 // Ellipse draws an ellipse at (x,y) with dimensions (w,h)
 func Ellipse(x, y, w, h VGfloat) {
+	checkthread()
+	if emptyshape(w, h) {
+		return
+	}
+	svgellipse(x, y, w, h, true)
+	if batchellipse(true, x, y, w, h) {
+		return
+	}
+	checkinit()
 	C.Ellipse(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h))
 }
 
+// EllipseOutline strokes an ellipse at (x,y) with dimensions (w,h), without filling it
+func EllipseOutline(x, y, w, h VGfloat) {
+	checkthread()
+	if emptyshape(w, h) {
+		return
+	}
+	svgellipse(x, y, w, h, false)
+	if batchellipse(false, x, y, w, h) {
+		return
+	}
+	checkinit()
+	C.EllipseOutline(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h))
+}
+
+// circlesegments is the number of segments set by SetCircleSegments
+var circlesegments int
+
+// SetCircleSegments sets how ellipses, circles, arcs and pie slices are drawn: with n > 0, as
+// polygons of n line segments per full turn, or with n = 0, the default, as true arcs, which
+// OpenVG flattens adaptively, by their size on the screen, so small circles stay cheap.
+// A fixed count trades smoothness for speed; large dials may want several hundred.
+func SetCircleSegments(n int) {
+	checkinit()
+	if n < 0 {
+		n = 0
+	}
+	circlesegments = n
+	C.SetCircleSegments(C.int(n))
+}
+
 // Circle draws a circle centered at (x,y), with radius r
 func Circle(x, y, r VGfloat) {
+	checkthread()
+	if emptyshape(r, r) {
+		return
+	}
+	svgellipse(x, y, r, r, true)
+	if batchellipse(true, x, y, r, r) {
+		return
+	}
+	checkinit()
 	C.Circle(C.VGfloat(x), C.VGfloat(y), C.VGfloat(r))
 }
 
+// CircleOutline strokes a circle centered at (x,y), with radius r, without filling it
+func CircleOutline(x, y, r VGfloat) {
+	checkthread()
+	if emptyshape(r, r) {
+		return
+	}
+	svgellipse(x, y, r, r, false)
+	if batchellipse(false, x, y, r, r) {
+		return
+	}
+	checkinit()
+	C.CircleOutline(C.VGfloat(x), C.VGfloat(y), C.VGfloat(r))
+}
+
 // Qbezier draws a quadratic bezier curve with extrema (sx, sy) and (ex, ey)
 // Control points are at (cx, cy)
 func Qbezier(sx, sy, cx, cy, ex, ey VGfloat) {
+	checkinit()
+	svgcurve("Q", true, sx, sy, cx, cy, ex, ey)
 	C.Qbezier(C.VGfloat(sx), C.VGfloat(sy), C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(ex), C.VGfloat(ey))
 }
 
+// QbezierOutline strokes a quadratic bezier curve, without filling it
+func QbezierOutline(sx, sy, cx, cy, ex, ey VGfloat) {
+	checkinit()
+	svgcurve("Q", false, sx, sy, cx, cy, ex, ey)
+	C.QbezierOutline(C.VGfloat(sx), C.VGfloat(sy), C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(ex), C.VGfloat(ey))
+}
+
 // Cbezier draws a cubic bezier curve with extrema (sx, sy) and (ex, ey).
 // Control points at (cx, cy) and (px, py)
 func Cbezier(sx, sy, cx, cy, px, py, ex, ey VGfloat) {
+	checkinit()
+	svgcurve("C", true, sx, sy, cx, cy, px, py, ex, ey)
 	C.Cbezier(C.VGfloat(sx), C.VGfloat(sy), C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(px), C.VGfloat(py), C.VGfloat(ex), C.VGfloat(ey))
 }
 
+// CbezierOutline strokes a cubic bezier curve, without filling it
+func CbezierOutline(sx, sy, cx, cy, px, py, ex, ey VGfloat) {
+	checkinit()
+	svgcurve("C", false, sx, sy, cx, cy, px, py, ex, ey)
+	C.CbezierOutline(C.VGfloat(sx), C.VGfloat(sy), C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(px), C.VGfloat(py), C.VGfloat(ex), C.VGfloat(ey))
+}
+
 // Arc draws an arc at (x,y) with dimensions (w,h).
-// the arc starts at the angle sa, extended to aext
+// the arc starts at the angle sa, extended to aext; an arc of empty dimensions draws nothing
 func Arc(x, y, w, h, sa, aext VGfloat) {
+	checkinit()
+	if emptyshape(w, h) {
+		return
+	}
 	C.Arc(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.VGfloat(sa), C.VGfloat(aext))
 }
 
+// ArcOutline strokes an arc at (x,y) with dimensions (w,h), without filling it;
+// an arc of empty dimensions draws nothing
+func ArcOutline(x, y, w, h, sa, aext VGfloat) {
+	checkinit()
+	if emptyshape(w, h) {
+		return
+	}
+	C.ArcOutline(C.VGfloat(x), C.VGfloat(y), C.VGfloat(w), C.VGfloat(h), C.VGfloat(sa), C.VGfloat(aext))
+}
+
+// Pie draws a filled pie slice centered at (cx,cy) with the specified radius.
+// The slice starts at startAngle and extends arcExtent degrees, counter-clockwise when positive.
+// A slice of zero or negative radius draws nothing.
+func Pie(cx, cy, radius, startAngle, arcExtent VGfloat) {
+	checkinit()
+	if emptyshape(radius, radius) {
+		return
+	}
+	C.Pie(C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(radius), C.VGfloat(startAngle), C.VGfloat(arcExtent))
+}
+
+// Ring draws a filled ring segment centered at (cx,cy), between innerR and outerR, as used for
+// circular progress indicators. The segment starts at startAngle and extends arcExtent degrees;
+// an extent of 360 draws a complete ring. Negative radii are taken as zero.
+func Ring(cx, cy, innerR, outerR, startAngle, arcExtent VGfloat) {
+	checkinit()
+	C.Ring(C.VGfloat(cx), C.VGfloat(cy), C.VGfloat(innerR), C.VGfloat(outerR), C.VGfloat(startAngle), C.VGfloat(arcExtent))
+}
+
 // poly converts coordinate slices
 func poly(x, y []VGfloat) (*C.VGfloat, *C.VGfloat, C.VGint) {
 	size := len(x)
-	if size != len(y) {
+	if size != len(y) || size == 0 {
 		return nil, nil, 0
 	}
 	px := make([]C.VGfloat, size)
@@ -491,54 +2098,769 @@ func poly(x, y []VGfloat) (*C.VGfloat, *C.VGfloat, C.VGint) {
 
 // Polygon draws a polygon with coordinate in x,y
 func Polygon(x, y []VGfloat) {
+	checkinit()
+	svgpoly("polygon", x, y, true, false)
 	px, py, np := poly(x, y)
 	if np > 0 {
 		C.Polygon(px, py, np)
 	}
 }
 
+// PolygonGradient draws a polygon with coordinates in x,y, filled with a linear gradient
+// across its bounding box, along the direction angle degrees counter-clockwise from the x axis:
+// 0 runs from left (offset 0) to right (offset 1), 90 in the direction of increasing y.
+// The gradient spans the box exactly, so its first and last colors meet the extreme corners.
+// The fill is left set to the gradient.
+func PolygonGradient(x, y []VGfloat, ramp []Offcolor, angle VGfloat) {
+	n := minint(len(x), len(y))
+	if n == 0 {
+		return
+	}
+	minx, maxx, miny, maxy := x[0], x[0], y[0], y[0]
+	for i := 1; i < n; i++ {
+		minx, maxx = minfloat(minx, x[i]), maxfloat(maxx, x[i])
+		miny, maxy = minfloat(miny, y[i]), maxfloat(maxy, y[i])
+	}
+	cx, cy := (minx+maxx)/2, (miny+maxy)/2
+	s, c := math.Sincos(radians(angle))
+	half := (float64(maxx-minx)*math.Abs(c) + float64(maxy-miny)*math.Abs(s)) / 2
+	dx, dy := VGfloat(c*half), VGfloat(s*half)
+	FillLinearGradient(cx-dx, cy-dy, cx+dx, cy+dy, ramp)
+	Polygon(x[:n], y[:n])
+}
+
+// PolygonOutline strokes a closed polygon with coordinates in x,y, without filling it
+func PolygonOutline(x, y []VGfloat) {
+	checkinit()
+	svgpoly("polygon", x, y, false, true)
+	px, py, np := poly(x, y)
+	if np > 0 {
+		C.PolygonOutline(px, py, np)
+	}
+}
+
 // Polyline draws a polyline with coordinates in x, y
 func Polyline(x, y []VGfloat) {
+	checkinit()
+	svgpoly("polyline", x, y, false, true)
 	px, py, np := poly(x, y)
 	if np > 0 {
 		C.Polyline(px, py, np)
 	}
 }
 
-// selectfont specifies the font by generic name
-func selectfont(s string) C.Fontinfo {
-	switch s {
-	case "sans":
-		return C.SansTypeface
-	case "serif":
-		return C.SerifTypeface
-	case "mono":
-		return C.MonoTypeface
-	case "helvetica":
-		return C.HelveticaTypeface
+// checkpoly validates coordinate slices for the error-returning polygon functions
+func checkpoly(x, y []VGfloat) error {
+	if len(x) != len(y) {
+		return fmt.Errorf("openvg: mismatched coordinates: %d x values, %d y values", len(x), len(y))
 	}
-	return C.SerifTypeface
+	if len(x) == 0 {
+		return fmt.Errorf("openvg: no coordinates")
+	}
+	return nil
 }
 
-// ClipRect limits the drawing area to specified rectangle
-func ClipRect(x, y, w, h int) {
-	C.ClipRect(C.VGint(x), C.VGint(y), C.VGint(w), C.VGint(h))
+// PolygonErr draws a polygon like Polygon, but returns an error
+// if the coordinate slices are empty or differ in length
+func PolygonErr(x, y []VGfloat) error {
+	if err := checkpoly(x, y); err != nil {
+		return err
+	}
+	Polygon(x, y)
+	return nil
 }
 
-// ClipEnd stops limiting drawing area to specified rectangle
-func ClipEnd() {
-	C.ClipEnd()
+// PolylineErr draws a polyline like Polyline, but returns an error
+// if the coordinate slices are empty or differ in length
+func PolylineErr(x, y []VGfloat) error {
+	if err := checkpoly(x, y); err != nil {
+		return err
+	}
+	Polyline(x, y)
+	return nil
 }
 
-// Text draws text whose aligment begins (x,y)
-func Text(x, y VGfloat, s string, font string, size int) {
-	t := C.CString(s)
+// unzip splits coordinate pairs into x and y slices
+func unzip(pts [][2]VGfloat) ([]VGfloat, []VGfloat) {
+	x := make([]VGfloat, len(pts))
+	y := make([]VGfloat, len(pts))
+	for i, p := range pts {
+		x[i], y[i] = p[0], p[1]
+	}
+	return x, y
+}
+
+// PolygonPts draws a polygon with vertices given as (x,y) pairs
+func PolygonPts(pts [][2]VGfloat) {
+	Polygon(unzip(pts))
+}
+
+// PolylinePts draws a polyline with vertices given as (x,y) pairs
+func PolylinePts(pts [][2]VGfloat) {
+	Polyline(unzip(pts))
+}
+
+// radians converts degrees to radians
+func radians(deg VGfloat) float64 {
+	return float64(deg) * math.Pi / 180
+}
+
+// Star draws a star centered at (cx,cy) with the specified number of points,
+// alternating between the outer and inner radii. The first point is at the top.
+func Star(cx, cy, outer, inner VGfloat, points int) {
+	if points < 2 {
+		return
+	}
+	n := points * 2
+	x := make([]VGfloat, n)
+	y := make([]VGfloat, n)
+	for i := 0; i < n; i++ {
+		r := outer
+		if i%2 == 1 {
+			r = inner
+		}
+		a := math.Pi/2 + float64(i)*math.Pi/float64(points)
+		x[i] = cx + r*VGfloat(math.Cos(a))
+		y[i] = cy + r*VGfloat(math.Sin(a))
+	}
+	Polygon(x, y)
+}
+
+// Ngon draws a regular polygon centered at (cx,cy) with the specified number of sides.
+// Unrotated, the first vertex is at the top; rotation is in degrees, counter-clockwise.
+func Ngon(cx, cy, radius VGfloat, sides int, rotation VGfloat) {
+	if sides < 3 {
+		return
+	}
+	x := make([]VGfloat, sides)
+	y := make([]VGfloat, sides)
+	for i := 0; i < sides; i++ {
+		a := math.Pi/2 + radians(rotation) + float64(i)*2*math.Pi/float64(sides)
+		x[i] = cx + radius*VGfloat(math.Cos(a))
+		y[i] = cy + radius*VGfloat(math.Sin(a))
+	}
+	Polygon(x, y)
+}
+
+// SmoothCurve strokes a smooth curve passing through the points, as a Catmull-Rom spline
+// drawn with cubic beziers. Two points are joined by a straight line; fewer, or
+// coordinate slices of differing lengths, draw nothing.
+func SmoothCurve(x, y []VGfloat) {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return
+	}
+	if n == 2 {
+		Line(x[0], y[0], x[1], y[1])
+		return
+	}
+	p := NewPath()
+	defer p.Destroy()
+	p.MoveTo(x[0], y[0])
+	catmullrom(p, x, y)
+	p.Draw(false, true)
+}
+
+// catmullrom adds to a path, at the first point, a Catmull-Rom spline through the points
+func catmullrom(p *Path, x, y []VGfloat) {
+	n := len(x)
+	for i := 0; i < n-1; i++ {
+		i0, i3 := i-1, i+2
+		if i0 < 0 {
+			i0 = 0
+		}
+		if i3 > n-1 {
+			i3 = n - 1
+		}
+		p.CubicTo(
+			x[i]+(x[i+1]-x[i0])/6, y[i]+(y[i+1]-y[i0])/6,
+			x[i+1]-(x[i3]-x[i])/6, y[i+1]-(y[i3]-y[i])/6,
+			x[i+1], y[i+1])
+	}
+}
+
+// Area draws the region between the polyline through the points and the horizontal line at
+// baseline, as under a line chart, closed by vertical edges at the first and last points.
+// Like Polygon, it is filled and stroked. Fewer than two points, or coordinate slices of
+// differing lengths, draw nothing.
+func Area(x, y []VGfloat, baseline VGfloat) {
+	area(x, y, baseline, false)
+}
+
+// AreaSmooth draws the region between the smooth curve through the points, as drawn by
+// SmoothCurve, and the horizontal line at baseline, like Area
+func AreaSmooth(x, y []VGfloat, baseline VGfloat) {
+	area(x, y, baseline, true)
+}
+
+// area draws the region under the polyline or curve through the points, down to baseline
+func area(x, y []VGfloat, baseline VGfloat, smooth bool) {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return
+	}
+	p := NewPath()
+	defer p.Destroy()
+	p.MoveTo(x[0], baseline)
+	p.LineTo(x[0], y[0])
+	if smooth {
+		catmullrom(p, x, y)
+	} else {
+		for i := 1; i < n; i++ {
+			p.LineTo(x[i], y[i])
+		}
+	}
+	p.LineTo(x[n-1], baseline)
+	p.Close()
+	p.Draw(true, true)
+}
+
+// RoundedPolyline strokes a polyline through the points, with each interior corner
+// replaced by a circular arc of the given radius. At corners too tight for the radius,
+// it is reduced so each arc uses at most half of either adjoining segment.
+// Coordinate slices of differing lengths, or fewer than two points, draw nothing.
+func RoundedPolyline(x, y []VGfloat, radius VGfloat) {
+	n := len(x)
+	if n != len(y) || n < 2 {
+		return
+	}
+	p := NewPath()
+	defer p.Destroy()
+	p.MoveTo(x[0], y[0])
+	for i := 1; i < n-1; i++ {
+		inx, iny := float64(x[i]-x[i-1]), float64(y[i]-y[i-1])
+		outx, outy := float64(x[i+1]-x[i]), float64(y[i+1]-y[i])
+		lin, lout := math.Hypot(inx, iny), math.Hypot(outx, outy)
+		if lin == 0 || lout == 0 || radius <= 0 {
+			p.LineTo(x[i], y[i])
+			continue
+		}
+		inx, iny, outx, outy = inx/lin, iny/lin, outx/lout, outy/lout
+		cross := inx*outy - iny*outx
+		// the angle the path turns through; no arc if it goes straight on, or doubles back
+		turn := math.Atan2(math.Abs(cross), inx*outx+iny*outy)
+		if turn < 1e-6 || math.Pi-turn < 1e-6 {
+			p.LineTo(x[i], y[i])
+			continue
+		}
+		// the arc meets each segment t from the corner
+		tan := math.Tan(turn / 2)
+		t := math.Min(float64(radius)*tan, math.Min(lin, lout)/2)
+		r := VGfloat(t / tan)
+		p.LineTo(x[i]-VGfloat(inx*t), y[i]-VGfloat(iny*t))
+		p.ArcTo(r, r, 0, false, cross > 0, x[i]+VGfloat(outx*t), y[i]+VGfloat(outy*t))
+	}
+	p.LineTo(x[n-1], y[n-1])
+	p.Draw(false, true)
+}
+
+// arrowhead draws a filled triangular head, size long and wide, with its tip at (x,y),
+// pointing in the direction (dx,dy); it returns the center of the head's base
+func arrowhead(x, y, dx, dy, size VGfloat) (VGfloat, VGfloat) {
+	d := VGfloat(math.Hypot(float64(dx), float64(dy)))
+	if d == 0 {
+		return x, y
+	}
+	ux, uy := dx/d, dy/d
+	bx, by := x-ux*size, y-uy*size
+	Polygon([]VGfloat{x, bx - uy*size/2, bx + uy*size/2}, []VGfloat{y, by + ux*size/2, by - ux*size/2})
+	return bx, by
+}
+
+// Arrow draws a line from (x1,y1) to (x2,y2), stroked, with a triangular head at (x2,y2),
+// headSize long and wide, filled with the current fill. If double is true, (x1,y1) has a head too.
+func Arrow(x1, y1, x2, y2, headSize VGfloat, double ...bool) {
+	dx, dy := x2-x1, y2-y1
+	x2, y2 = arrowhead(x2, y2, dx, dy, headSize)
+	if len(double) > 0 && double[0] {
+		x1, y1 = arrowhead(x1, y1, -dx, -dy, headSize)
+	}
+	Line(x1, y1, x2, y2)
+}
+
+// CurvedArrow draws a cubic bezier curve, as Cbezier but stroked only, with a triangular head
+// at its end (ex,ey) pointing along the curve, headSize long and wide, filled with the current fill.
+// If double is true, the start (sx,sy) has a head too.
+func CurvedArrow(sx, sy, cx, cy, px, py, ex, ey, headSize VGfloat, double ...bool) {
+	CbezierOutline(sx, sy, cx, cy, px, py, ex, ey)
+	dx, dy := ex-px, ey-py
+	if dx == 0 && dy == 0 { // the end control point is on the end; use the other
+		dx, dy = ex-cx, ey-cy
+	}
+	arrowhead(ex, ey, dx, dy, headSize)
+	if len(double) > 0 && double[0] {
+		dx, dy = sx-cx, sy-cy
+		if dx == 0 && dy == 0 {
+			dx, dy = sx-px, sy-py
+		}
+		arrowhead(sx, sy, dx, dy, headSize)
+	}
+}
+
+// Grid draws the border of the rectangle at (x,y) with dimensions (w,h), and nx vertical
+// and ny horizontal gridlines evenly spaced within it, using the current stroke.
+// If either count is negative nothing is drawn.
+func Grid(x, y, w, h VGfloat, nx, ny int) {
+	if nx < 0 || ny < 0 {
+		return
+	}
+	for i := 0; i <= nx+1; i++ {
+		gx := x + w*VGfloat(i)/VGfloat(nx+1)
+		Line(gx, y, gx, y+h)
+	}
+	for i := 0; i <= ny+1; i++ {
+		gy := y + h*VGfloat(i)/VGfloat(ny+1)
+		Line(x, gy, x+w, gy)
+	}
+}
+
+// Axis draws an axis of the specified length from (x,y), rightward if orientation is "horizontal"
+// or upward if "vertical", with ticks evenly spaced tick marks labeled with the values from
+// min to max, below a horizontal axis or left of a vertical one. The labels are formatted with
+// format, as for fmt.Sprintf, by default "%g", and drawn in the sans font, sized to the spacing
+// of the ticks, using the current fill; the line and tick marks use the current stroke.
+// There are at least two ticks, at the ends; unknown orientations are treated as "horizontal".
+func Axis(x, y, length VGfloat, min, max float64, ticks int, orientation string, format ...string) {
+	if ticks < 2 {
+		ticks = 2
+	}
+	f := "%g"
+	if len(format) > 0 {
+		f = format[0]
+	}
+	spacing := length / VGfloat(ticks-1)
+	size := int(spacing / 4)
+	if size < 8 {
+		size = 8
+	} else if size > 24 {
+		size = 24
+	}
+	tick := VGfloat(size) / 2
+	vertical := orientation == "vertical" || orientation == "v" || orientation == "y"
+	segments := make([][4]VGfloat, 0, ticks+1)
+	if vertical {
+		segments = append(segments, [4]VGfloat{x, y, x, up(y, length)})
+	} else {
+		segments = append(segments, [4]VGfloat{x, y, x + length, y})
+	}
+	for i := 0; i < ticks; i++ {
+		d := spacing * VGfloat(i)
+		label := fmt.Sprintf(f, min+(max-min)*float64(i)/float64(ticks-1))
+		if vertical {
+			ty := up(y, d)
+			segments = append(segments, [4]VGfloat{x - tick, ty, x, ty})
+			TextAligned(x-tick*2, ty, label, "sans", size, "right", "middle")
+		} else {
+			segments = append(segments, [4]VGfloat{x + d, y, x + d, up(y, -tick)})
+			TextAligned(x+d, up(y, -tick*2), label, "sans", size, "center", "top")
+		}
+	}
+	Lines(segments)
+}
+
+// ProgressBar draws a progress bar at (x,y) with dimensions (w,h): a track with fully rounded
+// ends, colored track, and over it a bar of the fill color spanning the fraction (0.0-1.0,
+// clamped) of its width from the left, or, if orientation is "vertical", of its height from the
+// bottom. The fill and stroke are unchanged.
+func ProgressBar(x, y, w, h, fraction VGfloat, track, fill color.RGBA, orientation ...string) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	fraction = clamp01(fraction)
+	SaveStyle()
+	defer RestoreStyle()
+	StrokeNone()
+	r := w
+	if h < r {
+		r = h
+	}
+	FillRGB(track.R, track.G, track.B, VGfloat(track.A)/255)
+	Roundrect(x, y, w, h, r, r)
+	if fraction == 0 {
+		return
+	}
+	FillRGB(fill.R, fill.G, fill.B, VGfloat(fill.A)/255)
+	if len(orientation) > 0 && orientation[0] == "vertical" {
+		fh := h * fraction
+		fy := y
+		if topleft {
+			fy = y + h - fh
+		}
+		Roundrect(x, fy, w, fh, r, minfloat(r, fh))
+		return
+	}
+	fw := w * fraction
+	Roundrect(x, y, fw, h, minfloat(r, fw), r)
+}
+
+// Bevel draws a rectangle at (x,y) with dimensions (w,h), filled with the current fill, with
+// beveled edges depth wide (at most half the smaller side) for a three-dimensional look: raised,
+// the default, with the top and left edges colored light and the bottom and right edges dark,
+// as if lit from the top left, or, if style is "inset", the other way round, as if sunken.
+// Top follows the origin set by SetOrigin. The fill and stroke are unchanged.
+func Bevel(x, y, w, h, depth VGfloat, light, dark color.RGBA, style ...string) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	SaveStyle()
+	defer RestoreStyle()
+	StrokeNone()
+	Rect(x, y, w, h)
+	d := minfloat(depth, minfloat(w, h)/2)
+	if d <= 0 {
+		return
+	}
+	if len(style) > 0 && style[0] == "inset" {
+		light, dark = dark, light
+	}
+	bottom, top := y, y+h
+	if topleft {
+		bottom, top = y+h, y
+	}
+	l, r := x, x+w
+	il, ir, ib, it := l+d, r-d, up(bottom, d), up(top, -d)
+	FillRGB(light.R, light.G, light.B, VGfloat(light.A)/255)
+	Polygon([]VGfloat{l, r, ir, il}, []VGfloat{top, top, it, it})
+	Polygon([]VGfloat{l, il, il, l}, []VGfloat{top, it, ib, bottom})
+	FillRGB(dark.R, dark.G, dark.B, VGfloat(dark.A)/255)
+	Polygon([]VGfloat{l, il, ir, r}, []VGfloat{bottom, ib, ib, bottom})
+	Polygon([]VGfloat{r, ir, ir, r}, []VGfloat{bottom, ib, it, top})
+}
+
+// Gauge draws a dial centered at (cx,cy) with the specified radius, showing value on the scale
+// from min to max: a track beginning at startAngle and extending sweep degrees, as for Ring, over
+// it an arc up to value's fraction along the track (clamped to 0.0-1.0), colored from ramp at
+// that fraction, a needle pointing to the value from a hub of that color, and the value below
+// the center, formatted with format, as for fmt.Sprintf, by default "%g", in the sans font.
+// The track and label use the current fill, and the needle the current stroke.
+// The fill and stroke are unchanged.
+func Gauge(cx, cy, radius VGfloat, min, max, value float64, startAngle, sweep VGfloat, ramp []Offcolor, format ...string) {
+	if radius <= 0 {
+		return
+	}
+	f := "%g"
+	if len(format) > 0 {
+		f = format[0]
+	}
+	var fraction VGfloat
+	if max != min {
+		fraction = clamp01(VGfloat((value - min) / (max - min)))
+	}
+	thickness := radius / 6
+	SaveStyle()
+	defer RestoreStyle()
+	TextMid(cx, up(cy, -radius/2), fmt.Sprintf(f, value), "sans", int(radius/5))
+	Ring(cx, cy, radius-thickness, radius, startAngle, sweep)
+	c := SampleRamp(ramp, fraction)
+	FillRGB(c.R, c.G, c.B, VGfloat(c.A)/255)
+	if fraction > 0 {
+		Ring(cx, cy, radius-thickness, radius, startAngle, sweep*fraction)
+	}
+	s, k := math.Sincos(radians(startAngle + sweep*fraction))
+	needle := radius - thickness*1.5
+	StrokeWidth(radius / 40)
+	Line(cx, cy, cx+needle*VGfloat(k), cy+needle*VGfloat(s))
+	StrokeNone()
+	Circle(cx, cy, radius/5)
+}
+
+// Checkerboard fills the rectangle at (x,y) with dimensions (w,h) with squares of side cell,
+// alternately colored c1 and c2, starting with c1 at (x,y), as a backdrop showing the
+// transparency of images drawn over it. Squares at the edges are cut to the rectangle.
+// The fill and stroke are unchanged.
+func Checkerboard(x, y, w, h, cell VGfloat, c1, c2 color.RGBA) {
+	if w <= 0 || h <= 0 || cell <= 0 {
+		return
+	}
+	SaveStyle()
+	defer RestoreStyle()
+	StrokeNone()
+	// each color's squares are drawn on their own, so translucent colors are not mixed
+	for k, c := range []color.RGBA{c1, c2} {
+		FillRGB(c.R, c.G, c.B, VGfloat(c.A)/255)
+		for j := 0; VGfloat(j)*cell < h; j++ {
+			cy := VGfloat(j) * cell
+			ch := minfloat(cell, h-cy)
+			for i := (j + k) % 2; VGfloat(i)*cell < w; i += 2 {
+				cx := VGfloat(i) * cell
+				Rect(x+cx, y+cy, minfloat(cell, w-cx), ch)
+			}
+		}
+	}
+}
+
+// selectfont specifies the font by generic name
+func selectfont(s string) C.Fontinfo {
+	return *fontref(s)
+}
+
+// fontref returns the font specified by generic name, by reference
+func fontref(s string) *C.Fontinfo {
+	switch s {
+	case "sans":
+		return &C.SansTypeface
+	case "serif":
+		return &C.SerifTypeface
+	case "mono":
+		return &C.MonoTypeface
+	case "helvetica":
+		return &C.HelveticaTypeface
+	}
+	return &C.SerifTypeface
+}
+
+// ClipRect limits the drawing area to specified rectangle
+func ClipRect(x, y, w, h int) {
+	checkinit()
+	C.ClipRect(C.VGint(x), C.VGint(y), C.VGint(w), C.VGint(h))
+}
+
+// ClipEnd stops limiting drawing area to specified rectangle
+func ClipEnd() {
+	checkinit()
+	C.ClipEnd()
+}
+
+// Scissor limits drawing to the union of the rectangles, each given as x, y, w, h,
+// in the same window coordinates as ClipRect. It is cheaper than masking for
+// rectangular areas; OpenVG supports at least 32 rectangles, and ignores any beyond its limit.
+// An empty set of rectangles hides all drawing. End with ScissorDisable.
+func Scissor(rects [][4]int) {
+	checkinit()
+	r := make([]C.VGint, len(rects)*4+1) // never empty, so &r[0] is valid
+	for i, rect := range rects {
+		for j, v := range rect {
+			r[i*4+j] = C.VGint(v)
+		}
+	}
+	C.Scissor(&r[0], C.int(len(rects)))
+}
+
+// DirtyRect begins a partial redraw of the rectangle at (x,y) with dimensions (w,h),
+// in window coordinates: the area is cleared to the clear color, and drawing is
+// limited to it until ScissorDisable. The rest of the window keeps the previous picture,
+// so a frame that changes only one area, such as a clock's seconds, can be drawn
+// with DirtyRect in place of Start, followed by End as usual.
+// End still presents the whole window.
+func DirtyRect(x, y, w, h int) {
+	if topleft {
+		AreaClear(x, y, w, h)
+	} else {
+		AreaClear(x, winheight-y-h, w, h)
+	}
+	Scissor([][4]int{{x, y, w, h}})
+}
+
+// ScissorDisable stops limiting drawing to the Scissor rectangles (or ClipRect)
+func ScissorDisable() {
+	checkinit()
+	C.ClipEnd()
+}
+
+// viewport is a rectangle of the window drawn into by Viewport
+type viewport struct {
+	x, y, w, h     int          // in window coordinates
+	cx, cy, cw, ch int          // the visible part, within any enclosing viewport
+	matrix         [9]C.VGfloat // transformation in effect at Viewport
+}
+
+// viewports is the stack of viewports begun by Viewport
+var viewports []viewport
+
+// Viewport begins drawing into the rectangle at (x,y) with dimensions (w,h), in window
+// coordinates, as a panel of its own: drawing is limited to the rectangle, and coordinates
+// are untransformed, with (0,0) at its lower left corner (or top left, as set by SetOrigin).
+// Within a viewport, a further Viewport is placed relative to it, and limited to it as well.
+// ViewportEnd returns to the enclosing viewport, or to the whole window. Viewports use the
+// scissor rectangles, so ClipRect and Scissor within a viewport replace its limits.
+func Viewport(x, y, w, h int) {
+	checkinit()
+	if w < 0 {
+		w = 0
+	}
+	if h < 0 {
+		h = 0
+	}
+	v := viewport{x: x, y: y, w: w, h: h}
+	v.cx, v.cy, v.cw, v.ch = x, y, w, h
+	if n := len(viewports); n > 0 {
+		p := viewports[n-1]
+		v.x, v.y = p.x+x, p.y+y
+		x0, y0 := maxint(v.x, p.cx), maxint(v.y, p.cy)
+		x1, y1 := minint(v.x+w, p.cx+p.cw), minint(v.y+h, p.cy+p.ch)
+		v.cx, v.cy, v.cw, v.ch = x0, y0, maxint(x1-x0, 0), maxint(y1-y0, 0)
+	}
+	C.GetMatrix(&v.matrix[0])
+	viewports = append(viewports, v)
+	v.apply()
+}
+
+// ViewportEnd ends drawing into the current viewport, restoring the transformation in effect
+// at its Viewport, and limiting drawing to the enclosing viewport, if any
+func ViewportEnd() {
+	checkinit()
+	n := len(viewports)
+	if n == 0 {
+		return
+	}
+	v := viewports[n-1]
+	viewports = viewports[:n-1]
+	if n > 1 {
+		viewports[n-2].apply()
+	} else {
+		C.ClipEnd()
+	}
+	C.LoadMatrix(&v.matrix[0])
+}
+
+// apply limits drawing to the viewport, with its origin at the corner
+func (v viewport) apply() {
+	C.ClipRect(C.VGint(v.cx), C.VGint(v.cy), C.VGint(v.cw), C.VGint(v.ch))
+	m := startmatrix(v.x, v.y)
+	C.LoadMatrix(&m[0])
+}
+
+// MaskBegin starts defining a mask: shapes and text drawn until MaskEnd
+// are not shown, but mark the area where later drawing will be visible.
+func MaskBegin() {
+	checkinit()
+	C.MaskBegin()
+}
+
+// MaskEnd finishes the mask begun with MaskBegin, limiting subsequent drawing
+// to the masked area. Masked drawing costs an extra per-pixel lookup,
+// so call MaskDisable when it is no longer needed.
+func MaskEnd() {
+	checkinit()
+	C.MaskEnd()
+}
+
+// MaskDisable stops limiting drawing to the mask
+func MaskDisable() {
+	checkinit()
+	C.MaskDisable()
+}
+
+// ClipBegin starts defining a clip path: shapes drawn until ClipUse are not shown,
+// but make up the area later drawing is limited to. Unlike ClipRect, the area may be any shape.
+// A clip begun while another is in use is intersected with it.
+func ClipBegin() {
+	checkinit()
+	C.ClipBegin()
+}
+
+// ClipUse limits subsequent drawing to the clip path defined since ClipBegin
+func ClipUse() {
+	checkinit()
+	C.ClipUse()
+}
+
+// ClipReset removes all clip paths
+func ClipReset() {
+	checkinit()
+	C.ClipReset()
+}
+
+// Text draws text whose aligment begins (x,y)
+func Text(x, y VGfloat, s string, font string, size int) {
+	checkinit()
+	svgtext(x, y, s, font, size, "start")
+	t := C.CString(s)
 	C.Text(C.VGfloat(x), C.VGfloat(y), t, selectfont(font), C.int(size))
 	C.free(unsafe.Pointer(t))
 }
 
+// TextFilled draws text beginning at (x,y), like Text, filled with the current fill paint,
+// whether a color, a gradient, such as set by FillLinearGradient, or a pattern. The gradient
+// or pattern is laid over the whole text, in the same coordinates as for shapes, so that a
+// headline can be filled with one gradient rather than each glyph repeating it.
+// Text fills the same way; this name makes the intent plain.
+func TextFilled(x, y VGfloat, s, font string, size int) {
+	Text(x, y, s, font, size)
+}
+
+// TextRun draws text beginning at (x,y), like Text, and returns its width, the advance to
+// where the next run of text should begin, so differently styled runs can be chained:
+//
+//	x += TextRun(x, y, "bold ", "sans", 20)
+//	TextRun(x, y, "plain", "serif", 20)
+func TextRun(x, y VGfloat, s string, font string, size int) VGfloat {
+	checkinit()
+	svgtext(x, y, s, font, size, "start")
+	t := C.CString(s)
+	f := selectfont(font)
+	C.Text(C.VGfloat(x), C.VGfloat(y), t, f, C.int(size))
+	w := C.TextWidth(t, f, C.int(size))
+	C.free(unsafe.Pointer(t))
+	return VGfloat(w)
+}
+
+// TextWithIcons draws text beginning at (x,y), like Text, with each ":name:" for which icons
+// has an image replaced by the image, inline: scaled, keeping its aspect ratio, to the height
+// of the line, from the font's depth below the baseline to its height above, with the text
+// continuing after it. Other ":name:" are drawn as they are, so colons in text are safe.
+func TextWithIcons(x, y VGfloat, s string, font string, size int, icons map[string]image.Image) {
+	ascent, depth := TextHeight(font, size), TextDepth(font, size)
+	h := ascent + depth
+	iy := textboxy(y, ascent, depth)
+	start := 0 // of the text not yet drawn
+	for i := 0; i < len(s); {
+		j := strings.IndexByte(s[i+1:], ':')
+		if s[i] != ':' || j < 0 {
+			i++
+			continue
+		}
+		im, ok := icons[s[i+1:i+1+j]]
+		if !ok || im.Bounds().Empty() {
+			i++
+			continue
+		}
+		x += TextRun(x, y, s[start:i], font, size)
+		b := im.Bounds()
+		w := h * VGfloat(b.Dx()) / VGfloat(b.Dy())
+		drawimage(x, iy, w, h, im, b)
+		x += w
+		i += j + 2
+		start = i
+	}
+	Text(x, y, s[start:], font, size)
+}
+
+// hasglyph reports whether a font has a glyph for r
+func hasglyph(r rune, font string) bool {
+	var nc C.int
+	return C.GlyphSegments(selectfont(font), C.int(r), &nc) >= 0
+}
+
+// TextEllipsis draws text beginning at (x,y), like Text, shortened if need be to fit within
+// maxWidth by removing characters from the end and appending an ellipsis: "…" if the font
+// has it, otherwise "...". The text drawn is returned, so truncation can be detected;
+// if not even the ellipsis fits, nothing is drawn and "" is returned.
+func TextEllipsis(x, y VGfloat, s string, font string, size int, maxWidth VGfloat) string {
+	checkinit()
+	if TextWidth(s, font, size) <= maxWidth {
+		Text(x, y, s, font, size)
+		return s
+	}
+	ellipsis := "…"
+	if !hasglyph('…', font) {
+		ellipsis = "..."
+	}
+	runes := []rune(s)
+	for n := len(runes) - 1; n >= 0; n-- {
+		t := strings.TrimRight(string(runes[:n]), " ") + ellipsis
+		if TextWidth(t, font, size) <= maxWidth {
+			Text(x, y, t, font, size)
+			return t
+		}
+	}
+	return ""
+}
+
 // TextMid draws text centered at (x,y)
 func TextMid(x, y VGfloat, s string, font string, size int) {
+	checkinit()
+	svgtext(x, y, s, font, size, "middle")
 	t := C.CString(s)
 	C.TextMid(C.VGfloat(x), C.VGfloat(y), t, selectfont(font), C.int(size))
 	C.free(unsafe.Pointer(t))
@@ -546,48 +2868,539 @@ func TextMid(x, y VGfloat, s string, font string, size int) {
 
 // TextEnd draws text end-aligned at (x,y)
 func TextEnd(x, y VGfloat, s string, font string, size int) {
+	checkinit()
+	svgtext(x, y, s, font, size, "end")
 	t := C.CString(s)
 	C.TextEnd(C.VGfloat(x), C.VGfloat(y), t, selectfont(font), C.int(size))
 	C.free(unsafe.Pointer(t))
 }
 
-// TextWidth returns the length of text at a specified font and size
+// TextAngle draws text rotated by deg degrees about (x,y); the prior transform is restored
+func TextAngle(x, y VGfloat, s string, font string, size int, deg VGfloat) {
+	checkinit()
+	t := C.CString(s)
+	C.TextAngle(C.VGfloat(x), C.VGfloat(y), t, selectfont(font), C.int(size), C.VGfloat(deg))
+	C.free(unsafe.Pointer(t))
+}
+
+// TextVertical draws text beginning at (x,y), reading bottom-to-top
+func TextVertical(x, y VGfloat, s string, font string, size int) {
+	TextAngle(x, y, s, font, size, 90)
+}
+
+// TextWidth returns the length of text at a specified font and size, matching what Text draws,
+// kerning included
 func TextWidth(s string, font string, size int) VGfloat {
+	checkinit()
 	t := C.CString(s)
 	defer C.free(unsafe.Pointer(t))
 	return VGfloat(C.TextWidth(t, selectfont(font), C.int(size)))
 }
 
+// TextTracking draws text beginning at (x,y), adding tracking to the spacing between glyphs.
+// Negative tracking tightens the text.
+func TextTracking(x, y VGfloat, s string, font string, size int, tracking VGfloat) {
+	checkinit()
+	t := C.CString(s)
+	C.TextTracking(C.VGfloat(x), C.VGfloat(y), t, selectfont(font), C.int(size), C.VGfloat(tracking))
+	C.free(unsafe.Pointer(t))
+}
+
+// TextFitCount returns the number of leading runes of s whose width, at a specified font and
+// size, is at most maxWidth: the length, in runes, of the longest prefix that fits
+func TextFitCount(s string, font string, size int, maxWidth VGfloat) int {
+	checkinit()
+	t := C.CString(s)
+	defer C.free(unsafe.Pointer(t))
+	return int(C.TextFitCount(t, selectfont(font), C.int(size), C.VGfloat(maxWidth)))
+}
+
+// KernPair returns the kerning adjustment between the characters a and b at a specified font
+// and size: the amount, usually negative, added to the advance of a before drawing b, as Text
+// and TextWidth do. It is zero if the font has no kerning for the pair. Of the built-in fonts,
+// "sans" and "serif" are kerned; "mono" has no kerning, and "helvetica" carries none.
+func KernPair(a, b rune, font string, size int) VGfloat {
+	checkinit()
+	return VGfloat(C.KernPair(selectfont(font), C.int(a), C.int(b), C.int(size)))
+}
+
+// TextPositioned draws each rune with its origin at its own x position, xs[i], on the baseline y,
+// with the current fill, as the basis for justification, tracking, or aligning tabular figures.
+// Runes without a glyph in the font are skipped. An error is returned, and nothing drawn,
+// if there is not one position per rune.
+func TextPositioned(y VGfloat, runes []rune, xs []VGfloat, font string, size int) error {
+	checkinit()
+	if len(runes) != len(xs) {
+		return fmt.Errorf("openvg: %d runes with %d positions", len(runes), len(xs))
+	}
+	if len(runes) == 0 {
+		return nil
+	}
+	chars := make([]C.int, len(runes))
+	pos := make([]C.VGfloat, len(xs))
+	for i, r := range runes {
+		chars[i] = C.int(r)
+		pos[i] = C.VGfloat(xs[i])
+	}
+	C.TextPositioned(C.VGfloat(y), &chars[0], &pos[0], C.int(len(runes)), selectfont(font), C.int(size))
+	return nil
+}
+
+// TextWidthTracking returns the length of text drawn by TextTracking
+func TextWidthTracking(s string, font string, size int, tracking VGfloat) VGfloat {
+	checkinit()
+	t := C.CString(s)
+	defer C.free(unsafe.Pointer(t))
+	return VGfloat(C.TextWidthTracking(t, selectfont(font), C.int(size), C.VGfloat(tracking)))
+}
+
 // TextHeight returns a font's height (ascent)
 func TextHeight(font string, size int) VGfloat {
+	checkinit()
 	return VGfloat(C.TextHeight(selectfont(font), C.int(size)))
 }
 
 // TextDepth returns the distance below the baseline for a specified font
 func TextDepth(font string, size int) VGfloat {
+	checkinit()
 	return VGfloat(C.TextDepth(selectfont(font), C.int(size)))
 }
 
+// TextBounds returns the width of text at a specified font and size, with the font's
+// height above and depth below the baseline, measured together
+func TextBounds(s string, font string, size int) (w, ascent, descent VGfloat) {
+	checkinit()
+	t := C.CString(s)
+	defer C.free(unsafe.Pointer(t))
+	var cw, ca, cd C.VGfloat
+	C.TextBounds(t, selectfont(font), C.int(size), &cw, &ca, &cd)
+	return VGfloat(cw), VGfloat(ca), VGfloat(cd)
+}
+
+// TextExtent returns the tight bounding box of the glyphs of text, relative to
+// the start of its baseline: (x,y) is the lower left corner, (w,h) the dimensions.
+// Text with no visible glyphs has an empty box at the origin.
+func TextExtent(s string, font string, size int) (x, y, w, h VGfloat) {
+	checkinit()
+	t := C.CString(s)
+	defer C.free(unsafe.Pointer(t))
+	var cx, cy, cw, ch C.VGfloat
+	C.TextExtent(t, selectfont(font), C.int(size), &cx, &cy, &cw, &ch)
+	return VGfloat(cx), VGfloat(cy), VGfloat(cw), VGfloat(ch)
+}
+
+// TextShadow draws text with a shadow in the specified color beneath it, offset by (dx,dy);
+// the text itself uses the current fill. The optional align, "left" (the default),
+// "center", or "right", says where (x,y) is in the text.
+func TextShadow(x, y VGfloat, s string, font string, size int, dx, dy VGfloat, shadow color.RGBA, align ...string) {
+	checkinit()
+	a := ""
+	if len(align) > 0 {
+		a = align[0]
+	}
+	C.FillSave()
+	FillRGB(shadow.R, shadow.G, shadow.B, VGfloat(shadow.A)/255)
+	textalign(x+dx, y+dy, s, font, size, a)
+	C.FillRestore()
+	textalign(x, y, s, font, size, a)
+}
+
+// TextOutline draws text surrounded by an outline, width units thick, in the specified color;
+// the text itself uses the current fill. The optional align is as for TextShadow.
+func TextOutline(x, y VGfloat, s string, font string, size int, width VGfloat, outline color.RGBA, align ...string) {
+	checkinit()
+	a := ""
+	if len(align) > 0 {
+		a = align[0]
+	}
+	C.FillSave()
+	FillRGB(outline.R, outline.G, outline.B, VGfloat(outline.A)/255)
+	for i := 0; i < 8; i++ { // the text, shifted around a circle of radius width
+		t := float64(i) * math.Pi / 4
+		textalign(x+width*VGfloat(math.Cos(t)), y+width*VGfloat(math.Sin(t)), s, font, size, a)
+	}
+	C.FillRestore()
+	textalign(x, y, s, font, size, a)
+}
+
+// TextLabel draws text on a rounded rectangle of the background color bg, sized to the text
+// with pad units of space around it, as for labels and tooltips. The box is not stroked;
+// the text uses the current fill. The optional align is as for TextShadow.
+func TextLabel(x, y VGfloat, s string, font string, size int, pad VGfloat, bg color.RGBA, align ...string) {
+	checkinit()
+	a := ""
+	if len(align) > 0 {
+		a = align[0]
+	}
+	w := TextWidth(s, font, size)
+	left := x
+	switch a {
+	case "center", "middle", "mid":
+		left -= w / 2
+	case "right", "end":
+		left -= w
+	}
+	ascent, depth := TextHeight(font, size), TextDepth(font, size)
+	SaveStyle()
+	StrokeNone()
+	FillRGB(bg.R, bg.G, bg.B, VGfloat(bg.A)/255)
+	Roundrect(left-pad, textboxy(y, ascent, depth)-pad, w+2*pad, ascent+depth+2*pad, pad, pad)
+	RestoreStyle()
+	textalign(x, y, s, font, size, a)
+}
+
+// linespacing is the default distance between the baselines of wrapped lines,
+// as a multiple of the font height plus depth
+const linespacing = 1.2
+
+// lineheight returns the default distance between baselines for a font and size
+func lineheight(font string, size int) VGfloat {
+	return (TextHeight(font, size) + TextDepth(font, size)) * linespacing
+}
+
+// wrapline is a line of wrapped text; end marks the last line of a paragraph
+type wrapline struct {
+	text string
+	end  bool
+}
+
+// wraplines breaks s into lines no wider than w, at spaces and newlines.
+// A word wider than w is placed on a line of its own.
+func wraplines(s string, font string, size int, w VGfloat) []wrapline {
+	var lines []wrapline
+	space := TextWidth(" ", font, size)
+	for _, para := range strings.Split(s, "\n") {
+		line, lw := "", VGfloat(0)
+		for _, word := range strings.Fields(para) {
+			ww := TextWidth(word, font, size)
+			if line != "" && lw+space+ww > w {
+				lines = append(lines, wrapline{line, false})
+				line, lw = "", 0
+			}
+			if line == "" {
+				line, lw = word, ww
+			} else {
+				line, lw = line+" "+word, lw+space+ww
+			}
+		}
+		lines = append(lines, wrapline{line, true})
+	}
+	return lines
+}
+
+// textalign draws text whose alignment, "left", "center", or "right", is at (x,y)
+func textalign(x, y VGfloat, s string, font string, size int, align string) {
+	switch align {
+	case "center", "middle", "mid":
+		TextMid(x, y, s, font, size)
+	case "right", "end":
+		TextEnd(x, y, s, font, size)
+	default:
+		Text(x, y, s, font, size)
+	}
+}
+
+// Label is a string to be drawn by Texts, at (X,Y) in Font at Size, aligned to the
+// point by Align: "left" (the default), "center", or "right"
+type Label struct {
+	X, Y  VGfloat
+	S     string
+	Font  string
+	Size  int
+	Align string
+}
+
+// labelbuf holds the labels passed to C by Texts, reused from call to call
+var labelbuf struct {
+	text   []byte
+	xy     []C.VGfloat
+	fonts  []*C.Fontinfo
+	sizes  []C.int
+	aligns []C.int
+}
+
+// Texts draws many labels, such as those of a chart, as if by Text, TextMid or TextEnd
+// for each, but in one call to C, without allocating a C string for each label
+func Texts(labels []Label) {
+	checkinit()
+	if len(labels) == 0 {
+		return
+	}
+	b := &labelbuf
+	b.text, b.xy, b.fonts, b.sizes, b.aligns = b.text[:0], b.xy[:0], b.fonts[:0], b.sizes[:0], b.aligns[:0]
+	for _, l := range labels {
+		s := l.S
+		if i := strings.IndexByte(s, 0); i >= 0 {
+			s = s[:i] // as C.CString would end it
+		}
+		b.text = append(append(b.text, s...), 0)
+		b.xy = append(b.xy, C.VGfloat(l.X), C.VGfloat(l.Y))
+		b.fonts = append(b.fonts, fontref(l.Font))
+		b.sizes = append(b.sizes, C.int(l.Size))
+		var align C.int
+		switch l.Align {
+		case "center", "middle", "mid":
+			align = 1
+		case "right", "end":
+			align = 2
+		}
+		b.aligns = append(b.aligns, align)
+		svgtext(l.X, l.Y, s, l.Font, l.Size, [...]string{"start", "middle", "end"}[align])
+	}
+	C.Texts(C.int(len(labels)), (*C.char)(unsafe.Pointer(&b.text[0])), &b.xy[0], &b.fonts[0], &b.sizes[0], &b.aligns[0])
+}
+
+// TextAligned draws text aligned to (x,y): horizontally, halign is "left", "center", or "right";
+// vertically, valign is "baseline", "top" (the font's height above the baseline),
+// "middle" (halfway between top and bottom), or "bottom" (the font's depth below the baseline).
+// Unknown alignments are treated as "left" and "baseline".
+func TextAligned(x, y VGfloat, s string, font string, size int, halign, valign string) {
+	switch valign {
+	case "top":
+		y = up(y, -TextHeight(font, size))
+	case "middle":
+		_, ascent, descent := TextBounds("", font, size)
+		y = up(y, -(ascent-descent)/2)
+	case "bottom":
+		y = up(y, TextDepth(font, size))
+	}
+	textalign(x, y, s, font, size, halign)
+}
+
+// Column is a cell of a row drawn by TextColumns: Text within Width, aligned by Align:
+// "left" (the default), "center", or "right"
+type Column struct {
+	Width VGfloat
+	Align string
+	Text  string
+}
+
+// TextColumns draws a row of a table with its baseline at y, the columns side by side from x,
+// each cell's text aligned within its column. Text too wide for its column is clipped to it,
+// with ClipRect, so then (x,y) is in window coordinates, and any clipping in effect is ended.
+// Draw successive rows at decreasing y, such as by TextHeight plus TextDepth apart.
+func TextColumns(x, y VGfloat, cols []Column, font string, size int) {
+	ascent, depth := TextHeight(font, size), TextDepth(font, size)
+	cy := textboxy(y, ascent, depth)
+	for _, c := range cols {
+		clipped := TextWidth(c.Text, font, size) > c.Width
+		if clipped {
+			ClipRect(int(math.Floor(float64(x))), int(math.Floor(float64(cy))),
+				int(math.Ceil(float64(c.Width))), int(math.Ceil(float64(ascent+depth))))
+		}
+		switch c.Align {
+		case "center", "middle", "mid":
+			textalign(x+c.Width/2, y, c.Text, font, size, c.Align)
+		case "right", "end":
+			textalign(x+c.Width, y, c.Text, font, size, c.Align)
+		default:
+			Text(x, y, c.Text, font, size)
+		}
+		if clipped {
+			ClipEnd()
+		}
+		x += c.Width
+	}
+}
+
+// textlines draws lines beginning with the baseline at y, each leading below the previous,
+// aligned within the width w from x; align is "left", "center", "right", or "justify"
+func textlines(x, y, w VGfloat, lines []wrapline, font string, size int, leading VGfloat, align string) {
+	for _, line := range lines {
+		switch align {
+		case "center", "middle", "mid":
+			textalign(x+w/2, y, line.text, font, size, align)
+		case "right", "end":
+			textalign(x+w, y, line.text, font, size, align)
+		case "justify":
+			if line.end {
+				Text(x, y, line.text, font, size)
+			} else {
+				textjustify(x, y, w, line.text, font, size)
+			}
+		default:
+			Text(x, y, line.text, font, size)
+		}
+		y = up(y, -leading)
+	}
+}
+
+// textjustify draws a line of text beginning at (x,y), spreading the space
+// between words so that it spans the width w
+func textjustify(x, y, w VGfloat, s string, font string, size int) {
+	words := strings.Fields(s)
+	if len(words) < 2 {
+		Text(x, y, s, font, size)
+		return
+	}
+	widths := make([]VGfloat, len(words))
+	var total VGfloat
+	for i, word := range words {
+		widths[i] = TextWidth(word, font, size)
+		total += widths[i]
+	}
+	gap := (w - total) / VGfloat(len(words)-1)
+	for i, word := range words {
+		Text(x, y, word, font, size)
+		x += widths[i] + gap
+	}
+}
+
+// TextWrap draws text beginning at (x,y), wrapped at spaces to fit the width w.
+// Newlines begin a new line, and successive baselines are leading apart;
+// if leading is zero the font's default line spacing is used.
+func TextWrap(x, y, w VGfloat, s string, font string, size int, leading VGfloat) {
+	if leading == 0 {
+		leading = lineheight(font, size)
+	}
+	textlines(x, y, w, wraplines(s, font, size, w), font, size, leading, "left")
+}
+
+// TextJustify draws text like TextWrap, but with the space between words
+// spread so that each line spans the width w, except the last line of each paragraph
+func TextJustify(x, y, w VGfloat, s string, font string, size int, leading VGfloat) {
+	if leading == 0 {
+		leading = lineheight(font, size)
+	}
+	textlines(x, y, w, wraplines(s, font, size, w), font, size, leading, "justify")
+}
+
+// linesheight returns the height of n lines of text, from the top of the first to the bottom of the last
+func linesheight(n int, font string, size int, leading VGfloat) VGfloat {
+	if n == 0 {
+		return 0
+	}
+	_, ascent, descent := TextBounds("", font, size)
+	return ascent + leading*VGfloat(n-1) + descent
+}
+
+// WrapHeight returns the height of text as drawn by TextWrap with the same arguments,
+// from the top of the first line to the bottom of the last, without drawing it
+func WrapHeight(width VGfloat, s string, font string, size int, leading VGfloat) VGfloat {
+	if leading == 0 {
+		leading = lineheight(font, size)
+	}
+	return linesheight(len(wraplines(s, font, size, width)), font, size, leading)
+}
+
+// TextBox draws text wrapped to fit within the rectangle with lower left corner at (x,y),
+// choosing the largest size, no more than maxSize, at which all the text fits.
+// Lines are aligned "left", "center", "right", or "justify" within the box.
+// The chosen size is returned; if the text does not fit even at size 1, nothing is drawn and 0 is returned.
+func TextBox(x, y, w, h VGfloat, s string, font string, maxSize int, align string) int {
+	for size := maxSize; size > 0; size-- {
+		lines := wraplines(s, font, size, w)
+		fits := true
+		for _, line := range lines {
+			if TextWidth(line.text, font, size) > w {
+				fits = false
+				break
+			}
+		}
+		leading := lineheight(font, size)
+		if !fits || linesheight(len(lines), font, size, leading) > h {
+			continue
+		}
+		top := y + h
+		if topleft {
+			top = y
+		}
+		textlines(x, up(top, -TextHeight(font, size)), w, lines, font, size, leading, align)
+		return size
+	}
+	return 0
+}
+
 // Translate translates the coordinate system to (x,y)
 func Translate(x, y VGfloat) {
+	checkinit()
 	C.Translate(C.VGfloat(x), C.VGfloat(y))
 }
 
 // Rotate rotates the coordinate system around the specifed angle
 func Rotate(r VGfloat) {
+	checkinit()
 	C.Rotate(C.VGfloat(r))
 }
 
 // Shear warps the coordinate system by (x,y)
 func Shear(x, y VGfloat) {
+	checkinit()
 	C.Shear(C.VGfloat(x), C.VGfloat(y))
 }
 
 // Scale scales the coordinate system by (x,y)
 func Scale(x, y VGfloat) {
+	checkinit()
 	C.Scale(C.VGfloat(x), C.VGfloat(y))
 }
 
+// WithTransform translates to (tx,ty), rotates by rotate degrees and scales by (sx,sy),
+// calls draw, then restores the previous transformation, even if draw panics
+func WithTransform(tx, ty, rotate, sx, sy VGfloat, draw func()) {
+	checkinit()
+	var m [9]C.VGfloat
+	C.GetMatrix(&m[0])
+	defer func() {
+		checkinit()
+		C.LoadMatrix(&m[0])
+	}()
+	C.Translate(C.VGfloat(tx), C.VGfloat(ty))
+	C.Rotate(C.VGfloat(rotate))
+	C.Scale(C.VGfloat(sx), C.VGfloat(sy))
+	draw()
+}
+
+// GroupOpacity calls draw, then composites everything it drew over the window at the given
+// opacity (0.0-1.0), so that overlapping shapes fade as one, rather than showing through
+// each other as they do when each is drawn with the alpha. It copies the window twice,
+// allocating two window sized images per call, so is slower than drawing directly.
+// Groups may be nested; the window is restored even if draw panics.
+func GroupOpacity(opacity VGfloat, draw func()) {
+	checkinit()
+	saved := C.GroupBegin()
+	defer func() {
+		checkinit()
+		C.GroupEnd(saved, C.VGfloat(clamp01(opacity)))
+	}()
+	draw()
+}
+
+// usermatrix returns the affine part of the current transformation, mapping user
+// coordinates (x,y) to window coordinates (a*x + c*y + e, b*x + d*y + f)
+func usermatrix() (a, b, c, d, e, f VGfloat) {
+	checkinit()
+	var m [9]C.VGfloat
+	C.GetMatrix(&m[0]) // column major
+	return VGfloat(m[0]), VGfloat(m[1]), VGfloat(m[3]), VGfloat(m[4]), VGfloat(m[6]), VGfloat(m[7])
+}
+
+// UserToWindow maps a point in user coordinates, as affected by Translate, Rotate,
+// Scale and Shear, to window coordinates
+func UserToWindow(ux, uy VGfloat) (VGfloat, VGfloat) {
+	a, b, c, d, e, f := usermatrix()
+	return a*ux + c*uy + e, b*ux + d*uy + f
+}
+
+// WindowToUserErr maps a point in window coordinates, such as a pointer position,
+// to user coordinates under the current transformation. An error is returned if the
+// transformation cannot be inverted, as after Scale(0, 0).
+func WindowToUserErr(wx, wy VGfloat) (VGfloat, VGfloat, error) {
+	a, b, c, d, e, f := usermatrix()
+	det := a*d - b*c
+	if det == 0 {
+		return 0, 0, fmt.Errorf("openvg: transformation is not invertible")
+	}
+	x, y := wx-e, wy-f
+	return (d*x - c*y) / det, (a*y - b*x) / det, nil
+}
+
+// WindowToUser is WindowToUserErr, returning (0,0) if the transformation cannot be inverted
+func WindowToUser(wx, wy VGfloat) (VGfloat, VGfloat) {
+	x, y, _ := WindowToUserErr(wx, wy)
+	return x, y
+}
+
 // SaveTerm saves terminal settings
 func SaveTerm() {
 	C.saveterm()
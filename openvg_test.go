@@ -0,0 +1,552 @@
+package openvg
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"testing"
+	"unsafe"
+)
+
+// The tests that draw need OpenVG, on an offscreen surface; they are skipped with -short,
+// or where it cannot be made. OpenVG is used from the goroutine that initialized it, so
+// TestMain keeps the main goroutine for drawing, running the tests on another.
+
+const testsize = 64 // width and height of the offscreen surface
+
+// drawq carries drawing from the tests to the main goroutine
+var drawq chan func()
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if testing.Short() {
+		os.Exit(m.Run())
+	}
+	if _, _, err := InitOffscreen(testsize, testsize); err != nil {
+		os.Exit(m.Run())
+	}
+	drawq = make(chan func())
+	code := make(chan int)
+	go func() {
+		code <- m.Run()
+	}()
+	for {
+		select {
+		case f := <-drawq:
+			f()
+		case c := <-code:
+			Shutdown()
+			os.Exit(c)
+		}
+	}
+}
+
+// ondisplay runs draw on the drawing goroutine, skipping the test if OpenVG is not available.
+// draw reports failures with t.Errorf, not t.Fatal.
+func ondisplay(t *testing.T, draw func()) {
+	t.Helper()
+	if drawq == nil {
+		t.Skip("OpenVG is not available")
+	}
+	done := make(chan struct{})
+	drawq <- func() {
+		defer close(done)
+		draw()
+	}
+	<-done
+}
+
+// wantpixel reports an error unless the pixel at (x,y) is c, within a tolerance of 2
+func wantpixel(t *testing.T, x, y int, c color.RGBA) {
+	t.Helper()
+	p := PixelAt(x, y)
+	near := func(a, b uint8) bool { return int(a)-int(b) <= 2 && int(b)-int(a) <= 2 }
+	if !near(p.R, c.R) || !near(p.G, c.G) || !near(p.B, c.B) || !near(p.A, c.A) {
+		t.Errorf("pixel (%d,%d) = %v, want %v", x, y, p, c)
+	}
+}
+
+var (
+	black = color.RGBA{0, 0, 0, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+)
+
+func TestBatchInTransform(t *testing.T) {
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		Background(0, 0, 0)
+		FillRGB(255, 0, 0, 1)
+		Batch()
+		WithTransform(32, 0, 0, 1, 1, func() {
+			Rect(0, 0, 8, 8)
+		})
+		Flush()
+		RenderFinish()
+		wantpixel(t, 36, 4, red)
+		wantpixel(t, 4, 4, black)
+	})
+}
+
+func TestAreaClear(t *testing.T) {
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		Background(0, 0, 0)
+		SetClearColor(255, 0, 0, 1)
+		AreaClear(8, 4, 16, 12) // top left corner, y down from the top
+		RenderFinish()
+		top := testsize - 1 // PixelAt has its origin at the lower left
+		wantpixel(t, 8, top-4, red)
+		wantpixel(t, 23, top-15, red)
+		wantpixel(t, 7, top-4, black)
+		wantpixel(t, 8, top-3, black)
+		wantpixel(t, 24, top-15, black)
+		wantpixel(t, 23, top-16, black)
+		wantpixel(t, 8, 4, black)
+	})
+}
+
+func TestKerning(t *testing.T) {
+	ondisplay(t, func() {
+		if k := KernPair('A', 'V', "sans", 20); k >= 0 {
+			t.Errorf(`KernPair('A', 'V', "sans", 20) = %g, want it negative`, k)
+		}
+		if k := KernPair('A', 'V', "mono", 20); k != 0 {
+			t.Errorf(`KernPair('A', 'V', "mono", 20) = %g, want 0`, k)
+		}
+		av := TextWidth("AV", "sans", 20)
+		a, v := TextWidth("A", "sans", 20), TextWidth("V", "sans", 20)
+		if want := a + v + KernPair('A', 'V', "sans", 20); av < want-0.01 || av > want+0.01 {
+			t.Errorf(`TextWidth("AV") = %g, want %g, the widths of A and V kerned`, av, want)
+		}
+	})
+}
+
+// ramp returns the stops made by makeramp, as offset, red, green, blue, alpha
+func ramp(r []Offcolor) [][5]float64 {
+	p, n := makeramp(r)
+	if p == nil {
+		return nil
+	}
+	v := unsafe.Slice(p, int(n)*5)
+	stops := make([][5]float64, n)
+	for i := range stops {
+		for j := range stops[i] {
+			stops[i][j] = float64(v[i*5+j])
+		}
+	}
+	return stops
+}
+
+func TestMakeramp(t *testing.T) {
+	blue := color.RGBA{0, 0, 255, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	tests := []struct {
+		name string
+		in   []Offcolor
+		want [][5]float64
+	}{
+		{"empty", nil, nil},
+		{"single", []Offcolor{{0.5, blue}}, [][5]float64{{0, 0, 0, 1, 1}, {1, 0, 0, 1, 1}}},
+		{"sorted", []Offcolor{{1, blue}, {0, red}}, [][5]float64{{0, 1, 0, 0, 1}, {1, 0, 0, 1, 1}}},
+		{"clamped", []Offcolor{{-0.5, red}, {1.5, blue}}, [][5]float64{{0, 1, 0, 0, 1}, {1, 0, 0, 1, 1}}},
+		{"stable", []Offcolor{{0.5, green}, {0.5, red}, {0, blue}}, [][5]float64{{0, 0, 0, 1, 1}, {0.5, 0, 1, 0, 1}, {0.5, 1, 0, 0, 1}}},
+	}
+	for _, test := range tests {
+		got := ramp(test.in)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: makeramp(%v) = %v, want %v", test.name, test.in, got, test.want)
+			continue
+		}
+		for i := range got {
+			for j := range got[i] {
+				if d := got[i][j] - test.want[i][j]; d < -1e-6 || d > 1e-6 {
+					t.Errorf("%s: makeramp(%v) = %v, want %v", test.name, test.in, got, test.want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestLinearmix(t *testing.T) {
+	tests := []struct {
+		a, b uint8
+		t    float64
+		want uint8
+	}{
+		{0, 255, 0.5, 188},
+		{255, 0, 0.5, 188},
+		{0, 255, 0.25, 137},
+		{0, 255, 0.75, 225},
+		{0, 255, 0, 0},
+		{0, 255, 1, 255},
+		{100, 100, 0.3, 100},
+		{50, 200, 0.5, 150},
+	}
+	for _, test := range tests {
+		if got := linearmix(test.a, test.b, test.t); got != test.want {
+			t.Errorf("linearmix(%d, %d, %g) = %d, want %d", test.a, test.b, test.t, got, test.want)
+		}
+	}
+	for v := 0; v < 256; v++ {
+		if got := lineartosrgb(srgbtolinear(uint8(v))); got != uint8(v) {
+			t.Errorf("lineartosrgb(srgbtolinear(%d)) = %d", v, got)
+		}
+	}
+}
+
+func TestLinearRamp(t *testing.T) {
+	SetGradientSpace("linear")
+	defer SetGradientSpace("srgb")
+	got := ramp([]Offcolor{{0, color.RGBA{0, 0, 0, 255}}, {1, color.RGBA{255, 255, 255, 255}}})
+	if len(got) != 9 {
+		t.Fatalf("a linear ramp of 2 stops has %d stops, want 9", len(got))
+	}
+	if mid := got[4]; mid[0] != 0.5 || uint8(mid[1]*255+0.5) != 188 {
+		t.Errorf("the middle stop of a linear ramp from black to white is %v, want offset 0.5, value 188", mid)
+	}
+	if first, last := got[0], got[8]; first[0] != 0 || first[1] != 0 || last[0] != 1 || last[1] != 1 {
+		t.Errorf("a linear ramp from black to white runs from %v to %v", first, last)
+	}
+}
+
+func TestPremultiply(t *testing.T) {
+	tests := []struct {
+		in, want []byte
+	}{
+		{[]byte{200, 100, 0, 128}, []byte{100, 50, 0, 128}},
+		{[]byte{10, 20, 30, 255}, []byte{10, 20, 30, 255}},
+		{[]byte{255, 255, 255, 0}, []byte{0, 0, 0, 0}},
+		{[]byte{255, 128, 0, 1}, []byte{1, 1, 0, 1}},
+		{[]byte{200, 100, 0, 128, 10, 20, 30, 255, 7}, []byte{100, 50, 0, 128, 10, 20, 30, 255, 7}}, // a partial pixel is left
+	}
+	for _, test := range tests {
+		got := append([]byte(nil), test.in...)
+		Premultiply(got)
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("Premultiply(%v) = %v, want %v", test.in, got, test.want)
+		}
+	}
+}
+
+func TestUnpremultiply(t *testing.T) {
+	tests := []struct {
+		in, want []byte
+	}{
+		{[]byte{100, 50, 0, 128}, []byte{199, 100, 0, 128}},
+		{[]byte{10, 20, 30, 255}, []byte{10, 20, 30, 255}},
+		{[]byte{90, 40, 20, 0}, []byte{0, 0, 0, 0}},             // transparent
+		{[]byte{200, 128, 50, 128}, []byte{255, 255, 100, 128}}, // beyond and at alpha
+		{[]byte{1, 0, 0, 1}, []byte{255, 0, 0, 1}},
+	}
+	for _, test := range tests {
+		got := append([]byte(nil), test.in...)
+		Unpremultiply(got)
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("Unpremultiply(%v) = %v, want %v", test.in, got, test.want)
+		}
+	}
+	// a round trip keeps colors to within the precision left by the alpha
+	for a := 1; a < 256; a++ {
+		for c := 0; c < 256; c++ {
+			pix := []byte{byte(c), 0, 0, byte(a)}
+			Premultiply(pix)
+			Unpremultiply(pix)
+			if d := int(pix[0]) - c; d*a > 255 || -d*a > 255 {
+				t.Fatalf("color %d alpha %d is %d after Premultiply and Unpremultiply", c, a, pix[0])
+			}
+		}
+	}
+}
+
+func TestHSL(t *testing.T) {
+	tests := []struct {
+		h, s, l VGfloat
+		want    color.RGBA
+	}{
+		{0, 1, 0.5, color.RGBA{255, 0, 0, 255}},
+		{120, 1, 0.5, color.RGBA{0, 255, 0, 255}},
+		{240, 1, 0.5, color.RGBA{0, 0, 255, 255}},
+		{60, 1, 0.5, color.RGBA{255, 255, 0, 255}},
+		{180, 1, 0.25, color.RGBA{0, 128, 128, 255}},
+		{300, 0.5, 0.75, color.RGBA{223, 159, 223, 255}},
+		{0, 0, 0.5, color.RGBA{128, 128, 128, 255}},
+		{0, 1, 1, color.RGBA{255, 255, 255, 255}},
+		{0, 1, 0, color.RGBA{0, 0, 0, 255}},
+		{-120, 1, 0.5, color.RGBA{0, 0, 255, 255}}, // hues wrap
+		{480, 1, 0.5, color.RGBA{0, 255, 0, 255}},
+		{0, 2, 0.5, color.RGBA{255, 0, 0, 255}}, // saturation is clamped
+	}
+	for _, test := range tests {
+		if got := HSL(test.h, test.s, test.l); got != test.want {
+			t.Errorf("HSL(%g, %g, %g) = %v, want %v", test.h, test.s, test.l, got, test.want)
+		}
+	}
+}
+
+func TestHSV(t *testing.T) {
+	tests := []struct {
+		h, s, v VGfloat
+		want    color.RGBA
+	}{
+		{0, 1, 1, color.RGBA{255, 0, 0, 255}},
+		{120, 1, 1, color.RGBA{0, 255, 0, 255}},
+		{240, 1, 1, color.RGBA{0, 0, 255, 255}},
+		{30, 1, 1, color.RGBA{255, 128, 0, 255}},
+		{210, 0.5, 0.5, color.RGBA{64, 96, 128, 255}},
+		{0, 0, 1, color.RGBA{255, 255, 255, 255}},
+		{0, 1, 0, color.RGBA{0, 0, 0, 255}},
+		{360, 1, 1, color.RGBA{255, 0, 0, 255}},
+	}
+	for _, test := range tests {
+		if got := HSV(test.h, test.s, test.v); got != test.want {
+			t.Errorf("HSV(%g, %g, %g) = %v, want %v", test.h, test.s, test.v, got, test.want)
+		}
+	}
+}
+
+func TestFillRule(t *testing.T) {
+	ondisplay(t, func() {
+		defer FillRule("nonzero")
+		for _, test := range []struct {
+			rule   string
+			center color.RGBA
+		}{{"evenodd", black}, {"nonzero", red}} {
+			Start(testsize, testsize)
+			Background(0, 0, 0)
+			FillRGB(255, 0, 0, 1)
+			FillRule(test.rule)
+			p := NewPath()
+			for _, r := range [][4]VGfloat{{8, 8, 48, 48}, {24, 24, 16, 16}} { // both counterclockwise
+				p.MoveTo(r[0], r[1])
+				p.LineTo(r[0]+r[2], r[1])
+				p.LineTo(r[0]+r[2], r[1]+r[3])
+				p.LineTo(r[0], r[1]+r[3])
+				p.Close()
+			}
+			p.Draw(true, false)
+			p.Destroy()
+			RenderFinish()
+			wantpixel(t, 12, 12, red)
+			wantpixel(t, 32, 32, test.center)
+		}
+	})
+}
+
+func TestReadbackColorSpace(t *testing.T) {
+	ondisplay(t, func() {
+		defer SetColorSpace(false)
+		Start(testsize, testsize)
+		Background(200, 100, 50)
+		RenderFinish()
+		im := Snapshot(testsize, testsize)
+		if c := im.NRGBAAt(10, 10); c != (color.NRGBA{200, 100, 50, 255}) {
+			t.Errorf("sRGB readback of a solid fill of (200,100,50) is %v", c)
+		}
+		SetColorSpace(true)
+		lin := func(v uint8) uint8 { return uint8(srgbtolinear(v)*255 + 0.5) }
+		wantpixel(t, 10, 10, color.RGBA{lin(200), lin(100), lin(50), 255})
+	})
+}
+
+func TestImagedataAlpha(t *testing.T) {
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	nrgba.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 128})
+	rgba := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	rgba.SetRGBA(0, 0, color.RGBA{128, 0, 0, 128}) // premultiplied
+	for _, im := range []image.Image{nrgba, rgba} {
+		data := imagedata(im, im.Bounds())
+		got := [4]uint8{uint8(data[0]), uint8(data[1]), uint8(data[2]), uint8(data[3])}
+		if got != [4]uint8{255, 0, 0, 128} {
+			t.Errorf("imagedata of 50%% alpha red as %T = %v, want [255 0 0 128]", im, got)
+		}
+	}
+}
+
+func TestImgAlpha(t *testing.T) {
+	im := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(im, im.Bounds(), image.NewUniform(color.NRGBA{255, 0, 0, 128}), image.Point{}, draw.Src)
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		Background(0, 0, 0)
+		Img(0, 0, im)
+		RenderFinish()
+		wantpixel(t, 4, 4, color.RGBA{128, 0, 0, 255})
+	})
+}
+
+func TestGradientPaints(t *testing.T) {
+	ondisplay(t, func() {
+		stops := []Offcolor{{0, red}, {1, color.RGBA{0, 0, 255, 255}}}
+		frame := func(i int) {
+			Start(testsize, testsize)
+			FillRGB(0, 128, 0, 1)
+			StrokeRGB(0, 0, 128, 1)
+			FillLinearGradient(0, 0, VGfloat(i), testsize, stops)
+			Rect(0, 0, testsize, testsize)
+			FillRadialGradient(32, 32, 32, 32, VGfloat(i), stops)
+			Circle(32, 32, 16)
+			SaveStyle()
+			FillLinearGradient(0, 0, testsize, VGfloat(i), stops)
+			RestoreStyle()
+			g, err := CreateLinearGradient(0, 0, testsize, testsize, stops)
+			if err == nil {
+				g.Destroy()
+			}
+			End()
+		}
+		frame(1)
+		n := paintcount()
+		for i := 2; i < 100; i++ {
+			frame(i)
+		}
+		if got := paintcount(); got != n {
+			t.Errorf("%d paints after 100 frames of gradients, %d after the first", got, n)
+		}
+	})
+}
+
+func TestFillPatternOrigin(t *testing.T) {
+	im := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	blue := color.RGBA{0, 0, 255, 255}
+	draw.Draw(im, image.Rect(0, 0, 8, 4), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(im, image.Rect(0, 4, 8, 8), image.NewUniform(blue), image.Point{}, draw.Src)
+	ondisplay(t, func() {
+		defer SetOrigin("lower-left")
+		for _, origin := range []string{"lower-left", "top-left"} {
+			SetOrigin(origin)
+			Start(testsize, testsize)
+			Background(0, 0, 0)
+			FillPattern(im, "fill")
+			Rect(0, 0, 8, 8)
+			RenderFinish()
+			top, bottom := 6, 1 // y in the image's top and bottom halves; PixelAt follows the origin
+			if origin == "top-left" {
+				top, bottom = 1, 6
+			}
+			wantpixel(t, 2, top, red)
+			wantpixel(t, 2, bottom, blue)
+			wantpixel(t, 12, top, black)
+		}
+	})
+}
+
+func TestCheckerboard(t *testing.T) {
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		Background(0, 0, 0)
+		Checkerboard(0, 0, 16, 16, 8, red, color.RGBA{0, 0, 255, 128})
+		RenderFinish()
+		blue := color.RGBA{0, 0, 128, 255} // half transparent blue over black, not over red
+		wantpixel(t, 4, 4, red)
+		wantpixel(t, 12, 4, blue)
+		wantpixel(t, 4, 12, blue)
+		wantpixel(t, 12, 12, red)
+		wantpixel(t, 20, 4, black)
+	})
+}
+
+// TestEmptyShapesChecked checks that empty shapes are checked like others: the tests are
+// not run on the drawing goroutine, so drawing from them panics, initialized or not
+func TestEmptyShapesChecked(t *testing.T) {
+	shapes := map[string]func(){
+		"Rect":             func() { Rect(0, 0, 0, 10) },
+		"RectOutline":      func() { RectOutline(0, 0, -1, 10) },
+		"Ellipse":          func() { Ellipse(0, 0, 10, 0) },
+		"EllipseOutline":   func() { EllipseOutline(0, 0, 0, 0) },
+		"Circle":           func() { Circle(0, 0, 0) },
+		"CircleOutline":    func() { CircleOutline(0, 0, -5) },
+		"Roundrect":        func() { Roundrect(0, 0, 0, 10, 2, 2) },
+		"RoundrectOutline": func() { RoundrectOutline(0, 0, 10, 0, 2, 2) },
+		"RoundrectCorners": func() { RoundrectCorners(0, 0, 0, 0, 1, 1, 1, 1) },
+		"Arc":              func() { Arc(0, 0, 0, 10, 0, 90) },
+		"ArcOutline":       func() { ArcOutline(0, 0, 10, -1, 0, 90) },
+		"Pie":              func() { Pie(0, 0, 0, 0, 90) },
+		"Ring":             func() { Ring(0, 0, -1, -2, 0, 90) },
+	}
+	for name, draw := range shapes {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("an empty %s, drawn off the drawing goroutine, did not panic", name)
+				}
+			}()
+			draw()
+		}()
+	}
+}
+
+// TestBatchedChecked checks that shapes are checked while batching, when they are added to
+// the batch rather than drawn: drawing from the tests' goroutine panics, initialized or not
+func TestBatchedChecked(t *testing.T) {
+	saved := batching
+	batching = true
+	defer func() {
+		batching = saved
+		pending.segments = pending.segments[:0]
+		pending.coords = pending.coords[:0]
+	}()
+	shapes := map[string]func(){
+		"Line":           func() { Line(0, 0, 10, 10) },
+		"Rect":           func() { Rect(0, 0, 10, 10) },
+		"RectOutline":    func() { RectOutline(0, 0, 10, 10) },
+		"Ellipse":        func() { Ellipse(0, 0, 10, 10) },
+		"EllipseOutline": func() { EllipseOutline(0, 0, 10, 10) },
+		"Circle":         func() { Circle(0, 0, 10) },
+		"CircleOutline":  func() { CircleOutline(0, 0, 10) },
+	}
+	for name, draw := range shapes {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s, batched off the drawing goroutine, did not panic", name)
+				}
+			}()
+			draw()
+		}()
+		if len(pending.segments) > 0 {
+			t.Errorf("%s, batched off the drawing goroutine, was added to the batch", name)
+		}
+	}
+}
+
+func TestNegativeStrokeWidth(t *testing.T) {
+	ondisplay(t, func() {
+		LastError() // clear any error left by earlier tests
+		StrokeWidth(3)
+		WithStrokeWidth(-1, func() {
+			if w := CurrentStrokeWidth(); w != 0 {
+				t.Errorf("stroke width within WithStrokeWidth(-1) = %g, want 0", w)
+			}
+		})
+		if w := CurrentStrokeWidth(); w != 3 {
+			t.Errorf("stroke width after WithStrokeWidth = %g, want 3", w)
+		}
+		StrokeWidth(-2)
+		Pie(32, 32, -5, 0, 90)
+		if err := LastError(); err != nil {
+			t.Errorf("negative stroke width and pie radius: %v", err)
+		}
+		StrokeWidth(1)
+	})
+}
+
+func TestClipWithFillNone(t *testing.T) {
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		Background(0, 0, 0)
+		FillNone()
+		ClipBegin()
+		Rect(0, 0, 8, 8)
+		ClipUse()
+		FillRGB(255, 0, 0, 1)
+		Rect(0, 0, testsize, testsize)
+		ClipReset()
+		RenderFinish()
+		wantpixel(t, 2, 2, red)
+		wantpixel(t, 12, 12, black)
+	})
+}
@@ -0,0 +1,217 @@
+package openvg
+
+/*
+#include <stdlib.h>
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import "unsafe"
+
+// Path is a shape built from move, line and curve segments.
+// Unlike the shape functions, which build and discard a path on every call,
+// a Path is kept by OpenVG and may be drawn any number of times.
+type Path struct {
+	handle   C.VGPath
+	segments []C.VGubyte
+	coords   []C.VGfloat
+	nseg     int     // segments already appended to handle
+	ncoord   int     // coordinates already appended to handle
+	cx, cy   VGfloat // current point
+	sx, sy   VGfloat // start of the current subpath
+}
+
+// NewPath makes an empty path; call Destroy when it is no longer needed
+func NewPath() *Path {
+	checkinit()
+	return &Path{handle: C.NewPath()}
+}
+
+// add records a segment and its coordinates
+func (p *Path) add(segment C.VGubyte, coords ...VGfloat) {
+	p.segments = append(p.segments, segment)
+	for _, c := range coords {
+		p.coords = append(p.coords, C.VGfloat(c))
+	}
+}
+
+// MoveTo begins a new subpath at (x,y)
+func (p *Path) MoveTo(x, y VGfloat) {
+	p.add(C.VG_MOVE_TO_ABS, x, y)
+	p.cx, p.cy = x, y
+	p.sx, p.sy = x, y
+}
+
+// LineTo adds a line from the current point to (x,y)
+func (p *Path) LineTo(x, y VGfloat) {
+	p.add(C.VG_LINE_TO_ABS, x, y)
+	p.cx, p.cy = x, y
+}
+
+// CubicTo adds a cubic bezier curve from the current point to (ex,ey),
+// with control points at (cx,cy) and (px,py)
+func (p *Path) CubicTo(cx, cy, px, py, ex, ey VGfloat) {
+	p.add(C.VG_CUBIC_TO_ABS, cx, cy, px, py, ex, ey)
+	p.cx, p.cy = ex, ey
+}
+
+// CurveTo is CubicTo, under the name it had before QuadTo was added
+func (p *Path) CurveTo(cx, cy, px, py, ex, ey VGfloat) {
+	p.CubicTo(cx, cy, px, py, ex, ey)
+}
+
+// QuadTo adds a quadratic bezier curve from the current point to (ex,ey),
+// with control point at (cx,cy)
+func (p *Path) QuadTo(cx, cy, ex, ey VGfloat) {
+	p.add(C.VG_QUAD_TO_ABS, cx, cy, ex, ey)
+	p.cx, p.cy = ex, ey
+}
+
+// ArcTo adds an elliptical arc from the current point to (x,y), with the semantics of
+// the SVG path "A" command: radii (rx,ry), the ellipse rotated by rotation degrees,
+// largeArc choosing the longer of the possible arcs, and sweep choosing the arc
+// drawn in the direction of increasing angle (counter-clockwise, as y increases up).
+// If either radius is zero a straight line is added instead.
+func (p *Path) ArcTo(rx, ry, rotation VGfloat, largeArc, sweep bool, x, y VGfloat) {
+	if rx == 0 || ry == 0 {
+		p.LineTo(x, y)
+		return
+	}
+	if rx < 0 {
+		rx = -rx
+	}
+	if ry < 0 {
+		ry = -ry
+	}
+	var segment C.VGubyte
+	switch {
+	case largeArc && sweep:
+		segment = C.VG_LCCWARC_TO_ABS
+	case largeArc:
+		segment = C.VG_LCWARC_TO_ABS
+	case sweep:
+		segment = C.VG_SCCWARC_TO_ABS
+	default:
+		segment = C.VG_SCWARC_TO_ABS
+	}
+	p.add(segment, rx, ry, rotation, x, y)
+	p.cx, p.cy = x, y
+}
+
+// Close closes the current subpath, returning to its starting point
+func (p *Path) Close() {
+	p.add(C.VG_CLOSE_PATH)
+	p.cx, p.cy = p.sx, p.sy
+}
+
+// flush sends segments added since the last draw to OpenVG
+func (p *Path) flush() {
+	n := len(p.segments) - p.nseg
+	if n == 0 {
+		return
+	}
+	var coords *C.VGfloat
+	if len(p.coords) > p.ncoord {
+		coords = &p.coords[p.ncoord]
+	}
+	C.PathAppend(p.handle, C.int(n), &p.segments[p.nseg], coords)
+	p.nseg = len(p.segments)
+	p.ncoord = len(p.coords)
+}
+
+// Draw renders the path, filled with the current fill paint and/or stroked
+func (p *Path) Draw(fill, stroke bool) {
+	checkinit()
+	p.flush()
+	C.PathDraw(p.handle, cbool(fill), cbool(stroke))
+}
+
+// Destroy frees the path
+func (p *Path) Destroy() {
+	checkinit()
+	C.PathDestroy(p.handle)
+	p.handle = 0
+	p.segments, p.coords = nil, nil
+	p.nseg, p.ncoord = 0, 0
+}
+
+// ArcTo strokes an elliptical arc from (x1,y1) to (x2,y2), as described for Path.ArcTo.
+// Coincident end points draw nothing, as in SVG.
+func ArcTo(x1, y1, rx, ry, rotation VGfloat, largeArc, sweep bool, x2, y2 VGfloat) {
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+	p := NewPath()
+	defer p.Destroy()
+	p.MoveTo(x1, y1)
+	p.ArcTo(rx, ry, rotation, largeArc, sweep, x2, y2)
+	p.Draw(false, true)
+}
+
+// TextOnPath draws text along a path, beginning offset units from its start,
+// each glyph centered on the path and rotated to follow its direction.
+// Glyphs whose centers fall beyond the end of the path are dropped.
+func TextOnPath(s string, path *Path, offset VGfloat, font string, size int) {
+	checkinit()
+	path.flush()
+	t := C.CString(s)
+	C.TextOnPath(path.handle, C.VGfloat(offset), t, selectfont(font), C.int(size))
+	C.free(unsafe.Pointer(t))
+}
+
+// GlyphPath returns the outline of a character at the specified font and size as a Path,
+// with its origin on the baseline at the left, to be transformed, filled, or stroked.
+// It returns nil if the font has no glyph for r.
+func GlyphPath(r rune, font string, size int) *Path {
+	checkinit()
+	f := selectfont(font)
+	var nc C.int
+	ns := C.GlyphSegments(f, C.int(r), &nc)
+	if ns < 0 {
+		return nil
+	}
+	p := NewPath()
+	if ns == 0 {
+		return p // a space
+	}
+	segments := make([]C.VGubyte, ns)
+	coords := make([]C.VGfloat, nc+1) // never empty
+	C.GlyphOutline(f, C.int(r), C.int(size), &segments[0], &coords[0])
+	c := func(i int) VGfloat { return VGfloat(coords[i]) }
+	i := 0
+	for _, s := range segments {
+		switch s {
+		case C.VG_MOVE_TO_ABS:
+			p.MoveTo(c(i), c(i+1))
+			i += 2
+		case C.VG_LINE_TO_ABS:
+			p.LineTo(c(i), c(i+1))
+			i += 2
+		case C.VG_QUAD_TO_ABS:
+			p.QuadTo(c(i), c(i+1), c(i+2), c(i+3))
+			i += 4
+		case C.VG_CUBIC_TO_ABS:
+			p.CubicTo(c(i), c(i+1), c(i+2), c(i+3), c(i+4), c(i+5))
+			i += 6
+		case C.VG_CLOSE_PATH:
+			p.Close()
+		}
+	}
+	return p
+}
+
+// ClipPath limits subsequent drawing to the filled area of the path, until ClipReset
+func ClipPath(p *Path) {
+	ClipBegin()
+	p.Draw(true, false)
+	ClipUse()
+}
+
+// cbool converts a bool for C functions taking int flags
+func cbool(b bool) C.int {
+	if b {
+		return 1
+	}
+	return 0
+}
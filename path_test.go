@@ -0,0 +1,20 @@
+package openvg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCurveTo(t *testing.T) {
+	var curve, cubic Path
+	curve.MoveTo(0, 0)
+	curve.CurveTo(1, 2, 3, 4, 5, 6)
+	cubic.MoveTo(0, 0)
+	cubic.CubicTo(1, 2, 3, 4, 5, 6)
+	if !reflect.DeepEqual(curve.segments, cubic.segments) || !reflect.DeepEqual(curve.coords, cubic.coords) {
+		t.Errorf("CurveTo added %v %v, CubicTo %v %v", curve.segments, curve.coords, cubic.segments, cubic.coords)
+	}
+	if curve.cx != 5 || curve.cy != 6 {
+		t.Errorf("current point after CurveTo = (%g,%g), want (5,6)", curve.cx, curve.cy)
+	}
+}
@@ -8,58 +8,147 @@ extern "C" {
 	extern void Rotate(VGfloat);
 	extern void Shear(VGfloat, VGfloat);
 	extern void Scale(VGfloat, VGfloat);
+	extern void GetMatrix(VGfloat *);
+	extern void LoadMatrix(const VGfloat *);
 	extern void Text(VGfloat, VGfloat, const char *, Fontinfo, int);
 	extern void TextMid(VGfloat, VGfloat, const char *, Fontinfo, int);
 	extern void TextEnd(VGfloat, VGfloat, const char *, Fontinfo, int);
+	extern void Texts(int, const char *, const VGfloat *, Fontinfo * const *, const int *, const int *);
+	extern void TextAngle(VGfloat, VGfloat, const char *, Fontinfo, int, VGfloat);
 	extern VGfloat TextWidth(const char *, Fontinfo, int);
+	extern int TextFitCount(const char *, Fontinfo, int, VGfloat);
+	extern void TextTracking(VGfloat, VGfloat, const char *, Fontinfo, int, VGfloat);
+	extern void TextPositioned(VGfloat, const int *, const VGfloat *, int, Fontinfo, int);
+	extern VGfloat TextWidthTracking(const char *, Fontinfo, int, VGfloat);
+	extern VGfloat KernPair(Fontinfo, int, int, int);
 	extern void Cbezier(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void Qbezier(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void Polygon(VGfloat *, VGfloat *, VGint);
 	extern void Polyline(VGfloat *, VGfloat *, VGint);
+	extern void PolygonOutline(VGfloat *, VGfloat *, VGint);
 	extern void Rect(VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void Line(VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void Lines(VGfloat *, int);
+	extern void Points(VGfloat *, VGfloat *, int, VGfloat);
+	extern void Dots(VGfloat *, VGfloat *, int);
 	extern void Roundrect(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void RoundrectCorners(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void Ellipse(VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void Circle(VGfloat, VGfloat, VGfloat);
 	extern void Arc(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void Pie(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void Ring(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void SetCircleSegments(int);
+	extern VGPath NewPath();
+	extern void PathAppend(VGPath, int, VGubyte *, VGfloat *);
+	extern void PathDraw(VGPath, int, int);
+	extern void PathDestroy(VGPath);
+	extern void DrawSegments(int, VGubyte *, VGfloat *, int, int);
+	extern void TextOnPath(VGPath, VGfloat, const char *, Fontinfo, int);
+	extern int GlyphSegments(Fontinfo, int, int *);
+	extern void GlyphOutline(Fontinfo, int, int, VGubyte *, VGfloat *);
 	extern void Image(VGfloat, VGfloat, int, int, const char *);
 	extern void Start(int, int);
+	extern void StartRGB(int, int, unsigned int, unsigned int, unsigned int, VGfloat);
+	extern void Origin(int);
 	extern void End();
+	extern void Flush();
+	extern void RenderFinish();
 	extern void SaveEnd(const char *);
+	extern void ReadPixels(int, int, int, int, VGubyte *);
+	extern void ReadColorSpace(int);
 	extern void Background(unsigned int, unsigned int, unsigned int);
 	extern void BackgroundRGB(unsigned int, unsigned int, unsigned int, VGfloat);
+	extern void BackgroundLinearGradient(VGfloat *, int);
+	extern void BackgroundRadialGradient(VGfloat *, int);
 	extern void init(int *, int *);
+	extern int initOffscreen(int, int);
+	extern void initDisplay(int, int *, int *);
+	extern int DisplaySize(int, int *, int *);
 	extern void finish();
 	extern void setfill(VGfloat[4]);
 	extern void setstroke(VGfloat[4]);
 	extern void StrokeWidth(VGfloat);
+	extern void SetStrokeWidth(VGfloat);
+	extern VGfloat GetStrokeWidth();
+	extern void GetStrokeStyle(VGint *, VGint *, VGfloat *);
+	extern void GetPaint(VGfloat *, VGfloat *, VGfloat *);
 	extern void Stroke(unsigned int, unsigned int, unsigned int, VGfloat);
 	extern void Fill(unsigned int, unsigned int, unsigned int, VGfloat);
 	extern void RGBA(unsigned int, unsigned int, unsigned int, VGfloat, VGfloat[4]);
 	extern void RGB(unsigned int, unsigned int, unsigned int, VGfloat[4]);
 	extern void FillLinearGradient(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat *, int);
 	extern void FillRadialGradient(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat *, int);
+	extern void GradientTransform(const VGfloat *);
+	extern void FillPattern(int, int, VGubyte *, VGTilingMode);
+	extern VGPaint CreateLinearGradient(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat *, int);
+	extern void SetFillPaint(VGPaint);
+	extern void DestroyGradient(VGPaint);
+	extern void DestroyPaint();
+	extern int PaintCount();
+	extern void FillSave();
+	extern void FillRestore();
+	extern void SaveStyle();
+	extern void RestoreStyle();
+	extern void FillNone();
+	extern void StrokeNone();
+	extern void FillRule(VGFillRule);
+	extern void BlendMode(VGBlendMode);
+	extern void RenderingQuality(VGRenderingQuality);
+	extern void ColorTransform(VGfloat *);
+	extern void ColorTransformOff();
+	extern VGImage GroupBegin();
+	extern void GroupEnd(VGImage, VGfloat);
+	extern VGImage NewLayer(int, int);
+	extern void LayerClear(VGImage, int, int);
+	extern int LayerBegin(VGImage);
+	extern void LayerEnd();
+	extern void LayerDraw(VGImage, VGfloat, VGfloat, int, int);
+	extern void LayerDestroy(VGImage);
+	extern void *NewSharedContext();
+	extern void DestroySharedContext(void *);
+	extern VGImage SharedUpload(int, int, VGubyte *);
 	extern void ClipRect(VGint x, VGint y, VGint w, VGint h);
 	extern void ClipEnd();
+	extern void Scissor(VGint *, int);
+	extern void MaskBegin();
+	extern void MaskEnd();
+	extern void MaskDisable();
+	extern void ClipBegin();
+	extern void ClipUse();
+	extern void ClipReset();
 	extern Fontinfo loadfont(const int *, const int *, const unsigned char *, const int *, const int *, const int *,
 				 const short *, int);
 	extern void unloadfont(VGPath *, int);
 	extern void makeimage(VGfloat, VGfloat, int, int, VGubyte *);
+	extern void makeimageformat(VGfloat, VGfloat, int, int, VGubyte *, VGImageFormat, int);
+	extern void DrawImage(VGfloat, VGfloat, VGfloat, VGfloat, int, int, VGubyte *);
+	extern void ImageQuality(VGImageQuality);
 	extern void saveterm();
 	extern void restoreterm();
 	extern void rawterm();
 
 	// Added by Paeryn
 	extern void initWindowSize(int x, int y, unsigned int w, unsigned int h);
+	extern void initWindowLayer(int layer);
 	extern VGfloat TextHeight(Fontinfo f, int pointsize);
 	extern VGfloat TextDepth(Fontinfo f, int pointsize);
-	extern void AreaClear(unsigned int x, unsigned int y, unsigned int w, unsigned int h);
+	extern void TextBounds(const char *, Fontinfo, int, VGfloat *, VGfloat *, VGfloat *);
+	extern void TextExtent(const char *, Fontinfo, int, VGfloat *, VGfloat *, VGfloat *, VGfloat *);
+	extern void AreaClear(int x, int y, int w, int h);
+	extern VGErrorCode GetError();
+	extern void ClearColor(unsigned int, unsigned int, unsigned int, VGfloat);
 	extern void WindowClear();
 	extern void WindowOpacity(unsigned int alpha);
+	extern unsigned int GetWindowOpacity();
 	extern void WindowPosition(int x, int y);
+	extern void WindowLayer(int layer);
+	extern int GetWindowLayer();
+	extern void SwapInterval(int n);
 	extern void CbezierOutline(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void QbezierOutline(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void RectOutline(VGfloat, VGfloat, VGfloat, VGfloat);
+	extern void RectDashed(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat *, int, VGfloat);
 	extern void RoundrectOutline(VGfloat, VGfloat, VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void EllipseOutline(VGfloat, VGfloat, VGfloat, VGfloat);
 	extern void CircleOutline(VGfloat, VGfloat, VGfloat);
@@ -0,0 +1,86 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// SharedContext is a second OpenVG context, sharing images with the one made by Init,
+// so that images can be uploaded on another goroutine, such as one decoding video,
+// without holding up the drawing. Its rules on goroutines are strict:
+//
+//   - InitSharedContext is called after Init, on a goroutine other than the drawing one,
+//     which is then locked to its thread; Upload and Close must be called on that goroutine.
+//   - The Layers returned by Upload are complete when it returns, and are then used like
+//     any other Layer, by the drawing goroutine only. Hand them over by a channel.
+//   - The shared context must be closed before Shutdown.
+//
+// Nothing else in this package may be called on the uploading goroutine.
+type SharedContext struct {
+	handle unsafe.Pointer
+	thread int
+}
+
+// InitSharedContext makes a shared context, for the calling goroutine, which must not be the
+// one that called Init
+func InitSharedContext() (*SharedContext, error) {
+	if !initialized {
+		return nil, fmt.Errorf("openvg: Init must be called before InitSharedContext")
+	}
+	runtime.LockOSThread()
+	if syscall.Gettid() == renderthread {
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("openvg: a shared context must be made on a goroutine other than the drawing one")
+	}
+	h := C.NewSharedContext()
+	if h == nil {
+		runtime.UnlockOSThread()
+		return nil, fmt.Errorf("openvg: unable to make a shared context")
+	}
+	return &SharedContext{handle: h, thread: syscall.Gettid()}, nil
+}
+
+// check panics unless the shared context is open and used on its goroutine
+func (s *SharedContext) check() {
+	if s.handle == nil {
+		panic("openvg: the shared context is closed")
+	}
+	if syscall.Gettid() != s.thread {
+		panic("openvg: a shared context must be used on the goroutine that made it")
+	}
+}
+
+// Upload copies an image into a new Layer, of the image's dimensions, returning when it is
+// complete, for the drawing goroutine to draw with Layer.Draw and free with Layer.Destroy
+func (s *SharedContext) Upload(im image.Image) (*Layer, error) {
+	s.check()
+	bounds := im.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("openvg: empty image")
+	}
+	w, h := bounds.Dx(), bounds.Dy()
+	data := imagedata(im, bounds)
+	img := C.SharedUpload(C.int(w), C.int(h), &data[0])
+	if img == C.VG_INVALID_HANDLE {
+		return nil, fmt.Errorf("openvg: unable to upload a %dx%d image", w, h)
+	}
+	return &Layer{image: img, width: w, height: h}, nil
+}
+
+// Close frees the shared context, unlocking its goroutine from its thread.
+// Layers uploaded with it remain valid.
+func (s *SharedContext) Close() {
+	s.check()
+	C.DestroySharedContext(s.handle)
+	s.handle = nil
+	runtime.UnlockOSThread()
+}
@@ -0,0 +1,283 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import "math"
+
+// vec is a point or direction, in float64 for the outline arithmetic
+type vec struct{ x, y float64 }
+
+func (a vec) add(b vec) vec        { return vec{a.x + b.x, a.y + b.y} }
+func (a vec) sub(b vec) vec        { return vec{a.x - b.x, a.y - b.y} }
+func (a vec) scale(s float64) vec  { return vec{a.x * s, a.y * s} }
+func (a vec) dot(b vec) float64    { return a.x*b.x + a.y*b.y }
+func (a vec) cross(b vec) float64  { return a.x*b.y - a.y*b.x }
+func (a vec) length() float64      { return math.Hypot(a.x, a.y) }
+func (a vec) left() vec            { return vec{-a.y, a.x} }
+func (a vec) unit() vec            { return a.scale(1 / a.length()) }
+func (a vec) rotate(t float64) vec { s, c := math.Sincos(t); return vec{a.x*c - a.y*s, a.x*s + a.y*c} }
+func (a vec) angle() float64       { return math.Atan2(a.y, a.x) }
+func (a vec) near(b vec) bool      { return math.Abs(a.x-b.x) < 1e-9 && math.Abs(a.y-b.y) < 1e-9 }
+
+// curvesteps returns the number of line segments approximating a curve
+// whose control polygon is length long
+func curvesteps(length float64) int {
+	n := int(length/4) + 1
+	if n > 64 {
+		n = 64
+	}
+	return n
+}
+
+// polyline is a subpath flattened to line segments
+type polyline struct {
+	points []vec
+	closed bool
+}
+
+// flatten returns the subpaths of the path as polylines, with curves and arcs
+// approximated by line segments
+func (p *Path) flatten() []polyline {
+	var lines []polyline
+	var cur polyline
+	var pos, start vec
+	finish := func() {
+		if len(cur.points) > 1 {
+			lines = append(lines, cur)
+		}
+		cur = polyline{}
+	}
+	lineto := func(v vec) {
+		if len(cur.points) == 0 {
+			cur.points = append(cur.points, pos)
+		}
+		if !v.near(cur.points[len(cur.points)-1]) {
+			cur.points = append(cur.points, v)
+		}
+		pos = v
+	}
+	i := 0
+	c := func() vec { // the next coordinate pair
+		v := vec{float64(p.coords[i]), float64(p.coords[i+1])}
+		i += 2
+		return v
+	}
+	for _, s := range p.segments {
+		switch s {
+		case C.VG_MOVE_TO_ABS:
+			finish()
+			pos = c()
+			start = pos
+		case C.VG_LINE_TO_ABS:
+			lineto(c())
+		case C.VG_QUAD_TO_ABS:
+			p0, p1, p2 := pos, c(), c()
+			n := curvesteps(p1.sub(p0).length() + p2.sub(p1).length())
+			for k := 1; k <= n; k++ {
+				t := float64(k) / float64(n)
+				u := 1 - t
+				lineto(p0.scale(u * u).add(p1.scale(2 * u * t)).add(p2.scale(t * t)))
+			}
+		case C.VG_CUBIC_TO_ABS:
+			p0, p1, p2, p3 := pos, c(), c(), c()
+			n := curvesteps(p1.sub(p0).length() + p2.sub(p1).length() + p3.sub(p2).length())
+			for k := 1; k <= n; k++ {
+				t := float64(k) / float64(n)
+				u := 1 - t
+				lineto(p0.scale(u * u * u).add(p1.scale(3 * u * u * t)).add(p2.scale(3 * u * t * t)).add(p3.scale(t * t * t)))
+			}
+		case C.VG_SCCWARC_TO_ABS, C.VG_SCWARC_TO_ABS, C.VG_LCCWARC_TO_ABS, C.VG_LCWARC_TO_ABS:
+			r := vec{float64(p.coords[i]), float64(p.coords[i+1])}
+			rot := float64(p.coords[i+2]) * math.Pi / 180
+			i += 3
+			end := c()
+			large := s == C.VG_LCCWARC_TO_ABS || s == C.VG_LCWARC_TO_ABS
+			sweep := s == C.VG_SCCWARC_TO_ABS || s == C.VG_LCCWARC_TO_ABS
+			for _, v := range arcpoints(pos, end, r, rot, large, sweep) {
+				lineto(v)
+			}
+		case C.VG_CLOSE_PATH:
+			lineto(start)
+			if len(cur.points) > 1 && cur.points[0].near(cur.points[len(cur.points)-1]) {
+				cur.points = cur.points[:len(cur.points)-1]
+			}
+			cur.closed = true
+			finish()
+			pos = start
+		}
+	}
+	finish()
+	return lines
+}
+
+// arcpoints approximates the elliptical arc from a to b, as added by Path.ArcTo, by points
+// following a, ending with b; the conversion to a center and angles follows the SVG specification
+func arcpoints(a, b, r vec, rot float64, large, sweep bool) []vec {
+	if a.near(b) {
+		return nil
+	}
+	r = vec{math.Abs(r.x), math.Abs(r.y)}
+	if r.x == 0 || r.y == 0 {
+		return []vec{b}
+	}
+	h := a.sub(b).scale(0.5).rotate(-rot) // the midpoint, in the ellipse's axes
+	if l := h.x*h.x/(r.x*r.x) + h.y*h.y/(r.y*r.y); l > 1 {
+		r = r.scale(math.Sqrt(l))
+	}
+	rx2, ry2 := r.x*r.x, r.y*r.y
+	num := rx2*ry2 - rx2*h.y*h.y - ry2*h.x*h.x
+	den := rx2*h.y*h.y + ry2*h.x*h.x
+	k := 0.0
+	if num > 0 && den > 0 {
+		k = math.Sqrt(num / den)
+	}
+	if large == sweep {
+		k = -k
+	}
+	cp := vec{k * r.x * h.y / r.y, -k * r.y * h.x / r.x}
+	center := cp.rotate(rot).add(a.add(b).scale(0.5))
+	t1 := vec{(h.x - cp.x) / r.x, (h.y - cp.y) / r.y}.angle()
+	dt := vec{(-h.x - cp.x) / r.x, (-h.y - cp.y) / r.y}.angle() - t1
+	if sweep && dt < 0 {
+		dt += 2 * math.Pi
+	} else if !sweep && dt > 0 {
+		dt -= 2 * math.Pi
+	}
+	n := curvesteps(math.Abs(dt) * math.Max(r.x, r.y))
+	points := make([]vec, 0, n)
+	for k := 1; k < n; k++ {
+		s, c := math.Sincos(t1 + dt*float64(k)/float64(n))
+		points = append(points, vec{r.x * c, r.y * s}.rotate(rot).add(center))
+	}
+	return append(points, b)
+}
+
+// outliner builds the outline of a stroke
+type outliner struct {
+	hw    float64 // half the stroke width
+	cap   C.VGint
+	join  C.VGint
+	miter float64
+	path  *Path
+	first bool
+}
+
+// point adds a point to the outline being built
+func (o *outliner) point(v vec) {
+	if o.first {
+		o.path.MoveTo(VGfloat(v.x), VGfloat(v.y))
+		o.first = false
+		return
+	}
+	o.path.LineTo(VGfloat(v.x), VGfloat(v.y))
+}
+
+// round adds points on the circle of radius hw around c, from direction a, turning
+// counter-clockwise (left) or clockwise through angle t
+func (o *outliner) round(c, a vec, t float64) {
+	n := int(math.Abs(t)/(math.Pi/16)) + 1
+	for k := 1; k < n; k++ {
+		o.point(c.add(a.rotate(t * float64(k) / float64(n)).scale(o.hw)))
+	}
+}
+
+// side adds the left side of the stroke along pts, with joins at its interior vertices,
+// or at every vertex of a closed polyline
+func (o *outliner) side(pts []vec, closed bool) {
+	n := len(pts)
+	dir := func(k int) vec { return pts[(k+1)%n].sub(pts[k%n]).unit() }
+	if !closed {
+		o.point(pts[0].add(dir(0).left().scale(o.hw)))
+	}
+	last := n - 1
+	if closed {
+		last = n + 1
+	}
+	for k := 1; k < last; k++ {
+		o.vertex(pts[k%n], dir(k-1), dir(k))
+	}
+	if !closed {
+		o.point(pts[n-1].add(dir(n - 2).left().scale(o.hw)))
+	}
+}
+
+// vertex adds the join on the left side of the stroke at c, between directions da and db
+func (o *outliner) vertex(c, da, db vec) {
+	na, nb := da.left(), db.left()
+	a, b := c.add(na.scale(o.hw)), c.add(nb.scale(o.hw))
+	turn := da.cross(db)
+	if turn > 0 { // the inside of a left turn; the overlap is covered by the nonzero fill
+		o.point(a)
+		o.point(c)
+		o.point(b)
+		return
+	}
+	o.point(a)
+	switch o.join {
+	case C.VG_JOIN_ROUND:
+		o.round(c, na, -math.Acos(math.Max(-1, math.Min(1, na.dot(nb)))))
+	case C.VG_JOIN_MITER:
+		if d := 1 + na.dot(nb); d > 0 && math.Sqrt(2/d) <= o.miter {
+			o.point(c.add(na.add(nb).scale(o.hw / d)))
+		}
+	}
+	o.point(b)
+}
+
+// end adds the cap at the end c of a polyline heading in direction d, from its left side to its right
+func (o *outliner) end(c, d vec) {
+	n := d.left()
+	switch o.cap {
+	case C.VG_CAP_ROUND:
+		o.round(c, n, -math.Pi)
+	case C.VG_CAP_SQUARE:
+		o.point(c.add(n.add(d).scale(o.hw)))
+		o.point(c.add(d.sub(n).scale(o.hw)))
+	}
+}
+
+// StrokePath returns a new Path outlining the stroke of p at the specified width, using the
+// current stroke cap, join and miter limit, so that the stroke can be filled like a shape,
+// for example with a gradient. Curves are approximated by line segments. Where the stroke
+// overlaps itself the outline winds around twice, so fill it after FillRule("nonzero").
+// The returned Path must be destroyed, like p.
+func StrokePath(p *Path, width VGfloat) *Path {
+	checkinit()
+	o := &outliner{hw: math.Abs(float64(width)) / 2, path: NewPath()}
+	var cap, join C.VGint
+	var miter C.VGfloat
+	C.GetStrokeStyle(&cap, &join, &miter)
+	o.cap, o.join, o.miter = cap, join, float64(miter)
+	if o.hw == 0 {
+		return o.path
+	}
+	for _, l := range p.flatten() {
+		pts := l.points
+		n := len(pts)
+		reversed := make([]vec, n)
+		for k, v := range pts {
+			reversed[n-1-k] = v
+		}
+		if l.closed && n > 2 {
+			// the two sides are separate rings, wound in opposite directions
+			o.first = true
+			o.side(pts, true)
+			o.path.Close()
+			o.first = true
+			o.side(reversed, true)
+			o.path.Close()
+			continue
+		}
+		o.first = true
+		o.side(pts, false)
+		o.end(pts[n-1], pts[n-1].sub(pts[n-2]).unit())
+		o.side(reversed, false)
+		o.end(pts[0], pts[0].sub(pts[1]).unit())
+		o.path.Close()
+	}
+	return o.path
+}
@@ -0,0 +1,210 @@
+package openvg
+
+/*
+#include "VG/openvg.h"
+#include "fontinfo.h"
+#include "shapes.h"
+*/
+import "C"
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// svgrecorder writes drawing as SVG elements, between BeginSVG and EndSVG
+type svgrecorder struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+// svgrec is the recording in progress, if any
+var svgrec *svgrecorder
+
+// svgfonts names the fonts for SVG viewers, falling back to generic families
+var svgfonts = map[string]string{
+	"sans":      "DejaVu Sans, sans-serif",
+	"serif":     "DejaVu Serif, serif",
+	"mono":      "DejaVu Sans Mono, monospace",
+	"helvetica": "Helvetica, Arial, sans-serif",
+}
+
+// BeginSVG starts recording the drawing to the SVG file filename, until EndSVG, for a vector
+// copy of what is shown. Start, backgrounds, Line, Lines, Rect, Roundrect, Ellipse, Circle, Polygon,
+// Polyline, the Bezier curves, their outlines, and Text, TextMid, TextEnd, TextRun and Texts are
+// written as the equivalent SVG elements, in the current transformation, fill and stroke
+// colors and stroke width, with the origin moved to the top left as SVG has it. Composites,
+// such as TextLabel, are recorded through the shapes they draw. Other effects, such as images,
+// clipping, masks and dashes, are not recorded, and shapes filled with a gradient or pattern
+// are recorded unfilled. Drawing to the screen continues as usual.
+func BeginSVG(filename string) error {
+	checkinit()
+	if svgrec != nil {
+		return fmt.Errorf("openvg: already recording to SVG")
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	svgrec = &svgrecorder{f: f, w: bufio.NewWriter(f)}
+	fmt.Fprintf(svgrec.w, "<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\">\n", winwidth, winheight)
+	return nil
+}
+
+// EndSVG ends the recording begun by BeginSVG, completing and closing the file
+func EndSVG() error {
+	r := svgrec
+	if r == nil {
+		return fmt.Errorf("openvg: not recording to SVG")
+	}
+	svgrec = nil
+	fmt.Fprintln(r.w, "</svg>")
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// svgpaint returns the attributes for the fill or stroke color c,
+// or none if its alpha is negative
+func svgpaint(attr string, c [4]C.VGfloat) string {
+	if c[3] < 0 {
+		return attr + `="none"`
+	}
+	v := func(f C.VGfloat) uint8 { return uint8(clamp01(VGfloat(f))*255 + 0.5) }
+	s := fmt.Sprintf(`%s="rgb(%d,%d,%d)"`, attr, v(c[0]), v(c[1]), v(c[2]))
+	if c[3] < 1 {
+		s += fmt.Sprintf(` %s-opacity="%g"`, attr, c[3])
+	}
+	return s
+}
+
+// element writes an SVG element with attributes attrs, in the current transformation followed
+// by local, filled and stroked with the current paint as specified, enclosing text if any
+func (r *svgrecorder) element(name, attrs, local string, fill, stroke bool, text string) {
+	var m [9]C.VGfloat
+	var fc, sc [4]C.VGfloat
+	var width C.VGfloat
+	C.GetMatrix(&m[0])
+	C.GetPaint(&fc[0], &sc[0], &width)
+	if !fill {
+		fc[3] = -1
+	}
+	if !stroke {
+		sc[3] = -1
+	}
+	// the transformation to the window, then the window's lower left origin to SVG's top left
+	fmt.Fprintf(r.w, `<%s %s transform="matrix(%g %g %g %g %g %g)%s" %s %s`,
+		name, attrs, m[0], -m[1], m[3], -m[4], m[6], C.VGfloat(winheight)-m[7], local, svgpaint("fill", fc), svgpaint("stroke", sc))
+	if sc[3] >= 0 {
+		fmt.Fprintf(r.w, ` stroke-width="%g"`, width)
+	}
+	if text == "" {
+		fmt.Fprintln(r.w, "/>")
+		return
+	}
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	fmt.Fprintf(r.w, ">%s</%s>\n", b.Bytes(), name)
+}
+
+// svgbackground records clearing the window to a color
+func svgbackground(r, g, b uint8, alpha VGfloat) {
+	if svgrec == nil {
+		return
+	}
+	c := [4]C.VGfloat{C.VGfloat(r) / 255, C.VGfloat(g) / 255, C.VGfloat(b) / 255, C.VGfloat(clamp01(alpha))}
+	fmt.Fprintf(svgrec.w, "<rect width=\"%d\" height=\"%d\" %s/>\n", winwidth, winheight, svgpaint("fill", c))
+}
+
+// svgrect records a rectangle, rounded if rw and rh are not zero
+func svgrect(x, y, w, h, rw, rh VGfloat, fill bool) {
+	if svgrec == nil {
+		return
+	}
+	if w < 0 {
+		x, w = x+w, -w
+	}
+	if h < 0 {
+		y, h = y+h, -h
+	}
+	attrs := fmt.Sprintf(`x="%g" y="%g" width="%g" height="%g"`, x, y, w, h)
+	if rw != 0 && rh != 0 {
+		attrs += fmt.Sprintf(` rx="%g" ry="%g"`, rw/2, rh/2)
+	}
+	svgrec.element("rect", attrs, "", fill, true, "")
+}
+
+// svgellipse records an ellipse centered at (x,y) with dimensions (w,h)
+func svgellipse(x, y, w, h VGfloat, fill bool) {
+	if svgrec == nil {
+		return
+	}
+	if w < 0 {
+		w = -w
+	}
+	if h < 0 {
+		h = -h
+	}
+	attrs := fmt.Sprintf(`cx="%g" cy="%g" rx="%g" ry="%g"`, x, y, w/2, h/2)
+	svgrec.element("ellipse", attrs, "", fill, true, "")
+}
+
+// svgline records a line
+func svgline(x1, y1, x2, y2 VGfloat) {
+	if svgrec == nil {
+		return
+	}
+	attrs := fmt.Sprintf(`x1="%g" y1="%g" x2="%g" y2="%g"`, x1, y1, x2, y2)
+	svgrec.element("line", attrs, "", false, true, "")
+}
+
+// svgpoly records a polygon or polyline, as name specifies
+func svgpoly(name string, x, y []VGfloat, fill, stroke bool) {
+	if svgrec == nil {
+		return
+	}
+	var b bytes.Buffer
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%g,%g", x[i], y[i])
+	}
+	svgrec.element(name, `points="`+b.String()+`"`, "", fill, stroke, "")
+}
+
+// svgcurve records a Bezier curve, the SVG path command cmd from the point
+// given by the first two coordinates through the rest
+func svgcurve(cmd string, fill bool, coords ...VGfloat) {
+	if svgrec == nil {
+		return
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "M%g,%g %s", coords[0], coords[1], cmd)
+	for i := 2; i < len(coords); i += 2 {
+		fmt.Fprintf(&b, " %g,%g", coords[i], coords[i+1])
+	}
+	svgrec.element("path", `d="`+b.String()+`"`, "", fill, true, "")
+}
+
+// svgtext records text at (x,y), anchored at its "start", "middle" or "end"
+func svgtext(x, y VGfloat, s, font string, size int, anchor string) {
+	if svgrec == nil || s == "" {
+		return
+	}
+	family, ok := svgfonts[font]
+	if !ok {
+		family = svgfonts["serif"]
+	}
+	// SVG glyphs rise toward negative y, so flip them unless the origin is already flipped
+	local := fmt.Sprintf(" translate(%g %g)", x, y)
+	if !topleft {
+		local += " scale(1 -1)"
+	}
+	attrs := fmt.Sprintf(`font-family="%s" font-size="%d" text-anchor="%s"`, family, size, anchor)
+	svgrec.element("text", attrs, local, true, false, s)
+}
@@ -0,0 +1,36 @@
+package openvg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSVGLinesTextRun(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "test.svg")
+	ondisplay(t, func() {
+		Start(testsize, testsize)
+		if err := BeginSVG(name); err != nil {
+			t.Errorf("BeginSVG: %v", err)
+			return
+		}
+		Lines([][4]VGfloat{{0, 0, 10, 10}, {10, 0, 0, 10}})
+		TextRun(4, 4, "run", "sans", 10)
+		if err := EndSVG(); err != nil {
+			t.Errorf("EndSVG: %v", err)
+		}
+		End()
+	})
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(b)
+	if n := strings.Count(svg, "<line "); n != 2 {
+		t.Errorf("Lines of 2 segments recorded %d lines:\n%s", n, svg)
+	}
+	if !strings.Contains(svg, ">run</text>") {
+		t.Errorf("TextRun not recorded:\n%s", svg)
+	}
+}
@@ -0,0 +1,219 @@
+package openvg
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DrawSVGPath draws the path described by SVG path data, as found in the "d"
+// attribute of an SVG path element, filled and stroked with the current settings.
+// All the SVG path commands are understood, absolute and relative:
+// M, L, H, V, C, S, Q, T, A and Z.
+// Coordinates are used as given; as SVG has y increasing downward, a drawing
+// may need to be flipped, for example with Scale(1, -1).
+// If the data cannot be parsed, an error is returned and nothing is drawn.
+func DrawSVGPath(d string) error {
+	p := NewPath()
+	defer p.Destroy()
+	if err := p.appendsvg(d); err != nil {
+		return err
+	}
+	p.Draw(true, true)
+	return nil
+}
+
+// svgscanner reads the numbers and flags of SVG path data
+type svgscanner struct {
+	s string
+	i int
+}
+
+// skip passes over whitespace and commas
+func (sc *svgscanner) skip() {
+	for sc.i < len(sc.s) {
+		switch sc.s[sc.i] {
+		case ' ', '\t', '\n', '\r', '\f', ',':
+			sc.i++
+		default:
+			return
+		}
+	}
+}
+
+// digits passes over a run of decimal digits, returning how many there were
+func (sc *svgscanner) digits() int {
+	start := sc.i
+	for sc.i < len(sc.s) && sc.s[sc.i] >= '0' && sc.s[sc.i] <= '9' {
+		sc.i++
+	}
+	return sc.i - start
+}
+
+// number reads a number, which may run directly into the next, as in "1.5.5" or "10-20"
+func (sc *svgscanner) number() (VGfloat, error) {
+	sc.skip()
+	start := sc.i
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+		sc.i++
+	}
+	n := sc.digits()
+	if sc.i < len(sc.s) && sc.s[sc.i] == '.' {
+		sc.i++
+		n += sc.digits()
+	}
+	if n == 0 {
+		sc.i = start
+		return 0, fmt.Errorf("openvg: svg path: expected a number at offset %d", start)
+	}
+	if sc.i < len(sc.s) && (sc.s[sc.i] == 'e' || sc.s[sc.i] == 'E') {
+		mark := sc.i
+		sc.i++
+		if sc.i < len(sc.s) && (sc.s[sc.i] == '+' || sc.s[sc.i] == '-') {
+			sc.i++
+		}
+		if sc.digits() == 0 {
+			sc.i = mark
+		}
+	}
+	v, err := strconv.ParseFloat(sc.s[start:sc.i], 32)
+	if err != nil {
+		return 0, fmt.Errorf("openvg: svg path: bad number %q at offset %d", sc.s[start:sc.i], start)
+	}
+	return VGfloat(v), nil
+}
+
+// numbers reads len(v) numbers into v
+func (sc *svgscanner) numbers(v []VGfloat) error {
+	var err error
+	for i := range v {
+		if v[i], err = sc.number(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flag reads an arc flag, a single "0" or "1"
+func (sc *svgscanner) flag() (bool, error) {
+	sc.skip()
+	if sc.i < len(sc.s) {
+		switch sc.s[sc.i] {
+		case '0':
+			sc.i++
+			return false, nil
+		case '1':
+			sc.i++
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("openvg: svg path: expected a flag at offset %d", sc.i)
+}
+
+// appendsvg adds the segments described by SVG path data to the path
+func (p *Path) appendsvg(d string) error {
+	sc := &svgscanner{s: d}
+	var cmd, prev byte // current command, and the previous one in upper case
+	var qx, qy VGfloat // last control point, for the S and T reflections
+	var v [7]VGfloat
+	for {
+		sc.skip()
+		if sc.i >= len(sc.s) {
+			return nil
+		}
+		c := sc.s[sc.i]
+		switch {
+		case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+			cmd = c
+			sc.i++
+		case cmd == 0:
+			return fmt.Errorf("openvg: svg path: expected a command at offset %d", sc.i)
+		}
+		if prev == 0 && cmd != 'M' && cmd != 'm' {
+			return fmt.Errorf("openvg: svg path: path must begin with a moveto")
+		}
+		var ox, oy VGfloat // origin of relative coordinates
+		if cmd >= 'a' {
+			ox, oy = p.cx, p.cy
+		}
+		upper := cmd &^ 0x20
+		switch upper {
+		case 'M':
+			if err := sc.numbers(v[:2]); err != nil {
+				return err
+			}
+			p.MoveTo(ox+v[0], oy+v[1])
+			cmd -= 'M' - 'L' // further coordinate pairs are implicit linetos
+		case 'L':
+			if err := sc.numbers(v[:2]); err != nil {
+				return err
+			}
+			p.LineTo(ox+v[0], oy+v[1])
+		case 'H':
+			if err := sc.numbers(v[:1]); err != nil {
+				return err
+			}
+			p.LineTo(ox+v[0], p.cy)
+		case 'V':
+			if err := sc.numbers(v[:1]); err != nil {
+				return err
+			}
+			p.LineTo(p.cx, oy+v[0])
+		case 'C':
+			if err := sc.numbers(v[:6]); err != nil {
+				return err
+			}
+			qx, qy = ox+v[2], oy+v[3]
+			p.CubicTo(ox+v[0], oy+v[1], qx, qy, ox+v[4], oy+v[5])
+		case 'S':
+			if err := sc.numbers(v[:4]); err != nil {
+				return err
+			}
+			cx, cy := p.cx, p.cy
+			if prev == 'C' || prev == 'S' {
+				cx, cy = 2*p.cx-qx, 2*p.cy-qy
+			}
+			qx, qy = ox+v[0], oy+v[1]
+			p.CubicTo(cx, cy, qx, qy, ox+v[2], oy+v[3])
+		case 'Q':
+			if err := sc.numbers(v[:4]); err != nil {
+				return err
+			}
+			qx, qy = ox+v[0], oy+v[1]
+			p.QuadTo(qx, qy, ox+v[2], oy+v[3])
+		case 'T':
+			if err := sc.numbers(v[:2]); err != nil {
+				return err
+			}
+			if prev == 'Q' || prev == 'T' {
+				qx, qy = 2*p.cx-qx, 2*p.cy-qy
+			} else {
+				qx, qy = p.cx, p.cy
+			}
+			p.QuadTo(qx, qy, ox+v[0], oy+v[1])
+		case 'A':
+			if err := sc.numbers(v[:3]); err != nil {
+				return err
+			}
+			large, err := sc.flag()
+			if err != nil {
+				return err
+			}
+			sweep, err := sc.flag()
+			if err != nil {
+				return err
+			}
+			if err := sc.numbers(v[3:5]); err != nil {
+				return err
+			}
+			if x, y := ox+v[3], oy+v[4]; x != p.cx || y != p.cy {
+				p.ArcTo(v[0], v[1], v[2], large, sweep, x, y)
+			}
+		case 'Z':
+			p.Close()
+			cmd = 0 // a command must follow
+		default:
+			return fmt.Errorf("openvg: svg path: unknown command %q at offset %d", c, sc.i-1)
+		}
+		prev = upper
+	}
+}
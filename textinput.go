@@ -0,0 +1,68 @@
+package openvg
+
+import (
+	"time"
+	"unicode"
+)
+
+// caretBlink is the time the TextInput caret spends on, and then off
+const caretBlink = 500 * time.Millisecond
+
+// TextInput is a single line text entry field, fed runes, for example from
+// the terminal in raw mode, and drawn each frame with a blinking caret
+type TextInput struct {
+	X, Y  VGfloat // start of the baseline
+	Font  string
+	Size  int
+	buf   []rune
+	value string
+}
+
+// NewTextInput makes an empty text input field whose text begins at (x,y)
+func NewTextInput(x, y VGfloat, font string, size int) *TextInput {
+	return &TextInput{X: x, Y: y, Font: font, Size: size}
+}
+
+// Feed handles a typed rune: backspace or delete removes the last rune,
+// return or newline commits the text, returning true, and other printable runes are appended
+func (ti *TextInput) Feed(r rune) bool {
+	switch {
+	case r == '\b' || r == 0x7f:
+		if len(ti.buf) > 0 {
+			ti.buf = ti.buf[:len(ti.buf)-1]
+		}
+	case r == '\r' || r == '\n':
+		ti.value = string(ti.buf)
+		ti.buf = ti.buf[:0]
+		return true
+	case unicode.IsPrint(r):
+		ti.buf = append(ti.buf, r)
+	}
+	return false
+}
+
+// Text returns the text being typed
+func (ti *TextInput) Text() string {
+	return string(ti.buf)
+}
+
+// Value returns the text committed by the last return
+func (ti *TextInput) Value() string {
+	return ti.value
+}
+
+// Draw draws the text being typed with the current fill, followed by the caret
+// while it is blinked on
+func (ti *TextInput) Draw() {
+	s := string(ti.buf)
+	Text(ti.X, ti.Y, s, ti.Font, ti.Size)
+	if time.Now().UnixNano()/int64(caretBlink)%2 != 0 {
+		return
+	}
+	w, ascent, descent := TextBounds(s, ti.Font, ti.Size)
+	cw := VGfloat(ti.Size) / 12
+	if cw < 1 {
+		cw = 1
+	}
+	Rect(ti.X+w+cw, textboxy(ti.Y, ascent, descent), cw, ascent+descent)
+}
@@ -0,0 +1,52 @@
+package openvg
+
+import "math"
+
+// tickerGap separates the end of a Ticker's text from its repetition
+const tickerGap = "     "
+
+// Ticker is text scrolling right to left across a strip, as on signage,
+// repeating without a break in the scrolling
+type Ticker struct {
+	Speed  VGfloat // distance scrolled per Draw
+	text   string
+	font   string
+	size   int
+	period VGfloat // width of the text and the gap following it; 0 until measured
+	offset VGfloat // distance scrolled into the current repetition
+}
+
+// NewTicker makes a ticker of the text s, in the specified font and size,
+// scrolling speed units each time it is drawn
+func NewTicker(s, font string, size int, speed VGfloat) *Ticker {
+	return &Ticker{Speed: speed, text: s, font: font, size: size}
+}
+
+// Draw draws the text with the current fill, its baseline at y, within the width w from x,
+// then scrolls it. The text is clipped to the strip with ClipRect, so (x,y) is in window
+// coordinates, and any clipping in effect is ended afterwards.
+func (t *Ticker) Draw(x, y, w VGfloat) {
+	if t.period == 0 {
+		t.period = TextWidth(t.text+tickerGap, t.font, t.size)
+	}
+	if t.period <= 0 || w <= 0 {
+		return
+	}
+	ascent, depth := TextHeight(t.font, t.size), TextDepth(t.font, t.size)
+	cy := textboxy(y, ascent, depth)
+	ClipRect(int(math.Floor(float64(x))), int(math.Floor(float64(cy))),
+		int(math.Ceil(float64(w))), int(math.Ceil(float64(ascent+depth))))
+	for px := x - t.offset; px < x+w; px += t.period {
+		Text(px, y, t.text, t.font, t.size)
+	}
+	ClipEnd()
+	t.offset = VGfloat(math.Mod(float64(t.offset+t.Speed), float64(t.period)))
+	if t.offset < 0 {
+		t.offset += t.period
+	}
+}
+
+// Reset scrolls the text back to its start
+func (t *Ticker) Reset() {
+	t.offset = 0
+}